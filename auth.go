@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WorkspaceUser - учетная запись администратора рабочего пространства
+type WorkspaceUser struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func validateWorkspaceUsers(users []WorkspaceUser) error {
+	seen := make(map[string]bool)
+	for _, user := range users {
+		if user.Username == "" || user.Password == "" {
+			return fmt.Errorf("у пользователя должны быть заданы username и password")
+		}
+		if strings.Contains(user.Username, ":") {
+			return fmt.Errorf("имя пользователя %q не может содержать двоеточие", user.Username)
+		}
+		if seen[user.Username] {
+			return fmt.Errorf("пользователь %q указан повторно", user.Username)
+		}
+		seen[user.Username] = true
+	}
+	return nil
+}
+
+// Страницы и API, которые только читают данные, но доступны лишь администраторам:
+// редактор и обращения к внешним адресам и репозиторию от имени сервера
+var adminPaths = map[string]bool{
+	"/edit":         true,
+	"/api/discover": true,
+	"/api/gitops":   true,
+}
+
+// Изменяющие запросы, доступные без входа: ручная проверка всех сервисов
+// с главной страницы и вебхук обслуживания, у которого свой токен
+var publicActions = map[string]bool{
+	"/api/services/check":      true,
+	"/api/webhook/maintenance": true,
+}
+
+// requiresAuth определяет, нужен ли для запроса вход администратора.
+// Пространство без пользователей доступно всем, как и раньше
+func (ws *Workspace) requiresAuth(r *http.Request) bool {
+	if len(ws.users) == 0 {
+		return false
+	}
+	if adminPaths[r.URL.Path] {
+		return true
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return false
+	}
+	return !publicActions[r.URL.Path]
+}
+
+// authorize проверяет имя и пароль из Basic-авторизации. Пароли сравниваются
+// за постоянное время, как и токен вебхуков
+func (ws *Workspace) authorize(w http.ResponseWriter, r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if ok {
+		for _, user := range ws.users {
+			nameOK := subtle.ConstantTimeCompare([]byte(username), []byte(user.Username)) == 1
+			passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(user.Password)) == 1
+			if nameOK && passwordOK {
+				return true
+			}
+		}
+	}
+
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, "Web Monitor: "+ws.ID))
+	w.WriteHeader(http.StatusUnauthorized)
+	writeError(w, "Требуется вход администратора пространства")
+	return false
+}
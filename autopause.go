@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AutoPausePolicy - приостановка сервисов, недоступных слишком долго. Такие
+// сервисы проверяются реже, а напоминание о них приходит раз в день
+type AutoPausePolicy struct {
+	// Через сколько непрерывной недоступности сервис приостанавливается (0 - никогда)
+	After time.Duration
+	// Как часто проверяются приостановленные сервисы
+	Interval time.Duration
+	// Время ежедневной сводки по местному времени
+	DigestHour   int
+	DigestMinute int
+}
+
+const defaultPausedInterval = time.Hour
+
+// parseDigestTime разбирает время ежедневной сводки вида "09:00"
+func parseDigestTime(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("неверное время сводки %q (ожидается ЧЧ:ММ)", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// nextDigest возвращает ближайший после now момент отправки сводки
+func (p AutoPausePolicy) nextDigest(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), p.DigestHour, p.DigestMinute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// dueForCheck сообщает, нужно ли проверять сервис в очередном проходе:
+// сервис в окне мониторинга, не на обслуживании и, если приостановлен,
+// давно не проверялся
+func (m *Monitor) dueForCheck(service Service, now time.Time) bool {
+	if !service.Monitored(now) || service.Maintenance.Active(now) {
+		return false
+	}
+	if service.PausedAt == nil {
+		return true
+	}
+	return service.LastCheck == nil || now.Sub(*service.LastCheck) >= m.autoPause.Interval
+}
+
+// updatePause отслеживает длительность недоступности сервиса, приостанавливает
+// его по истечении autoPause.After и возобновляет после восстановления.
+// Вызывается под блокировкой монитора
+func (m *Monitor) updatePause(i int, status bool, at time.Time) {
+	service := &m.services[i]
+
+	if status {
+		service.DownSince = nil
+		if service.PausedAt != nil {
+			service.PausedAt = nil
+			fmt.Printf("Сервис %s снова доступен, проверки возобновлены\n", service.Name)
+			m.saveToFile()
+		}
+		return
+	}
+
+	if service.DownSince == nil {
+		service.DownSince = &at
+	}
+	if m.autoPause.After > 0 && service.PausedAt == nil && at.Sub(*service.DownSince) >= m.autoPause.After {
+		service.PausedAt = &at
+		fmt.Printf("Сервис %s недоступен с %s и приостановлен, проверка раз в %s\n",
+			service.Name, service.DownSince.Format("02.01.2006 15:04"), m.autoPause.Interval)
+		m.saveToFile()
+	}
+}
+
+// ResumeService снимает приостановку вручную. Отсчет недоступности
+// начинается заново, чтобы сервис не приостановился при следующей проверке
+func (m *Monitor) ResumeService(id string) (Service, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.services {
+		if m.services[i].ID == id {
+			m.services[i].PausedAt = nil
+			m.services[i].DownSince = nil
+			m.saveToFile()
+			return m.services[i], true
+		}
+	}
+	return Service{}, false
+}
+
+// PausedDigest - ежедневное напоминание о приостановленных сервисах
+type PausedDigest struct {
+	Time     time.Time
+	Services []Service
+}
+
+// DigestNotifier - уведомитель, который умеет доставлять ежедневную сводку
+type DigestNotifier interface {
+	NotifyDigest(digest PausedDigest) error
+}
+
+func (d PausedDigest) Message() string {
+	items := make([]string, len(d.Services))
+	for i, service := range d.Services {
+		items[i] = fmt.Sprintf("%s (%s) недоступен с %s", service.Name, service.URL, service.DownSince.Format("02.01.2006 15:04"))
+	}
+	return fmt.Sprintf("Приостановлено сервисов: %d. %s", len(d.Services), strings.Join(items, "; "))
+}
+
+// sendDigest отправляет сводку, если есть приостановленные сервисы
+func (m *Monitor) sendDigest(now time.Time) {
+	digest := PausedDigest{Time: now}
+	for _, service := range m.GetServices() {
+		if service.PausedAt != nil && service.DownSince != nil {
+			digest.Services = append(digest.Services, service)
+		}
+	}
+	if len(digest.Services) == 0 {
+		return
+	}
+
+	fmt.Println(digest.Message())
+	for _, n := range m.notifiers {
+		if dn, ok := n.(DigestNotifier); ok {
+			if err := dn.NotifyDigest(digest); err != nil {
+				log.Printf("Ошибка отправки сводки о приостановленных сервисах: %v", err)
+			}
+		}
+	}
+}
+
+// RunDigest раз в день напоминает о приостановленных сервисах до отмены ctx
+func (m *Monitor) RunDigest(ctx context.Context) {
+	for {
+		timer := time.NewTimer(time.Until(m.autoPause.nextDigest(time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case now := <-timer.C:
+			m.sendDigest(now)
+		}
+	}
+}
+
+func (ws *Workspace) resumeServiceHandler(w http.ResponseWriter, r *http.Request, service Service) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resumed, ok := ws.monitor.ResumeService(service.ID)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeError(w, "Сервис не найден")
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"success": true,
+		"service": newServiceView(resumed),
+	})
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Ответы короче этого размера не сжимаются: заголовки сжатия съедают выигрыш
+const minCompressSize = 1024
+
+// Типы содержимого, которые имеет смысл сжимать
+var compressibleTypes = []string{"text/", "application/json", "application/javascript"}
+
+// compressWriter - общий интерфейс gzip.Writer и brotli.Writer
+type compressWriter interface {
+	io.WriteCloser
+	Reset(io.Writer)
+}
+
+// Кодировки в порядке предпочтения при равном q: brotli сжимает HTML и JSON
+// заметно лучше gzip. Уровень brotli 5 - компромисс между размером и
+// нагрузкой на процессор для ответов, которые формируются на каждый запрос
+var encodings = []struct {
+	name    string
+	writers *sync.Pool
+}{
+	{"br", &sync.Pool{New: func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, 5)
+	}}},
+	{"gzip", &sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}},
+}
+
+// withCompression сжимает ответы brotli или gzip, если клиент их принимает.
+// Дашборд опрашивает /api/services каждые несколько секунд, и на медленных
+// каналах большой список сервисов сжимается в разы
+func withCompression(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if r.Method == http.MethodHead || encoding < 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding выбирает по Accept-Encoding кодировку с наибольшим q
+// и возвращает ее индекс в encodings или -1, если сжатие не принимается
+func negotiateEncoding(header string) int {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if v, err := strconv.ParseFloat(value, 64); strings.TrimSpace(name) == "q" && err == nil {
+				q = v
+			}
+		}
+		weights[coding] = q
+	}
+
+	best, bestQ := -1, 0.0
+	for i, encoding := range encodings {
+		q, ok := weights[encoding.name]
+		if !ok {
+			// "*" относится ко всем кодировкам, не перечисленным явно
+			q = weights["*"]
+		}
+		if q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	return best
+}
+
+// compressResponseWriter решает, сжимать ли ответ, по заголовкам и первому
+// блоку данных: короткие ответы, несжимаемые типы, 304 и уже сжатые ответы
+// передаются как есть
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding int
+	status   int
+	buf      []byte
+	decided  bool
+	cw       compressWriter
+}
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < minCompressSize {
+			return len(p), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.cw != nil {
+		return w.cw.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// decide отправляет заголовки и накопленные данные, сжимая их при необходимости
+func (w *compressResponseWriter) decide() error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if len(w.buf) >= minCompressSize && w.compressible() {
+		header.Set("Content-Encoding", encodings[w.encoding].name)
+		header.Del("Content-Length")
+		w.cw = encodings[w.encoding].writers.Get().(compressWriter)
+		w.cw.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.cw != nil {
+		_, err = w.cw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressResponseWriter) compressible() bool {
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	if w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := w.Header().Get("Content-Type")
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close дописывает короткий ответ или завершает сжатый поток
+func (w *compressResponseWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			return nil
+		}
+		if err := w.decide(); err != nil {
+			return err
+		}
+	}
+	if w.cw == nil {
+		return nil
+	}
+	err := w.cw.Close()
+	w.cw.Reset(io.Discard)
+	encodings[w.encoding].writers.Put(w.cw)
+	w.cw = nil
+	return err
+}
+
+// writeCached отдает тело с ETag и Cache-Control: no-cache. Браузер
+// переспрашивает сервер при каждой загрузке, но если содержимое не
+// изменилось, получает 304 без тела. ETag слабый, так как одно и то же
+// содержимое передается и сжатым, и без сжатия
+func writeCached(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:12]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// etagMatches сравнивает If-None-Match с ETag слабым сравнением (RFC 9110)
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
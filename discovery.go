@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Распространенные пути health-эндпоинтов в порядке предпочтения
+var healthPaths = []string{"/healthz", "/health", "/status"}
+
+// Таймаут одной пробы при поиске health-эндпоинта
+const discoveryTimeout = 5 * time.Second
+
+// HealthCandidate - результат пробы одного пути
+type HealthCandidate struct {
+	Path        string  `json:"path"`
+	URL         string  `json:"url"`
+	StatusCode  int     `json:"status_code,omitempty"`
+	ContentType string  `json:"content_type,omitempty"`
+	LatencyMs   float64 `json:"latency_ms"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// discoveryBase приводит введенный адрес к корню сайта: "example.com" и
+// "https://example.com/page" дают "https://example.com"
+func discoveryBase(raw string) (*url.URL, bool) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, false
+	}
+	return &url.URL{Scheme: u.Scheme, Host: u.Host}, true
+}
+
+// probe запрашивает адрес и возвращает код ответа и тип содержимого
+func (m *Monitor) probe(ctx context.Context, path, target string) HealthCandidate {
+	candidate := HealthCandidate{Path: path, URL: target}
+
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		candidate.Error = err.Error()
+		return candidate
+	}
+	req.Header.Set("User-Agent", m.userAgent)
+
+	start := time.Now()
+	resp, err := m.client.Do(req)
+	candidate.LatencyMs = roundTo(float64(time.Since(start))/float64(time.Millisecond), 1)
+	if err != nil {
+		candidate.Error = err.Error()
+		return candidate
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	candidate.StatusCode = resp.StatusCode
+	candidate.ContentType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return candidate
+}
+
+// DiscoverHealth опрашивает распространенные health-пути сайта и предлагает
+// первый, отвечающий 200 OK. Если сайт отвечает 200 на любой путь (например,
+// одностраничное приложение), HTML-ответы не считаются health-эндпоинтами
+func (m *Monitor) DiscoverHealth(ctx context.Context, base *url.URL) ([]HealthCandidate, string) {
+	// Заведомо несуществующий путь показывает, как сайт отвечает на неизвестные адреса
+	paths := append([]string{"/" + newServiceID()}, healthPaths...)
+
+	results := make([]HealthCandidate, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			target := *base
+			target.Path = path
+			results[i] = m.probe(ctx, path, target.String())
+		}(i, path)
+	}
+	wg.Wait()
+
+	catchAll := results[0].StatusCode == http.StatusOK
+	candidates := results[1:]
+
+	suggested := ""
+	for _, candidate := range candidates {
+		if candidate.StatusCode != http.StatusOK {
+			continue
+		}
+		if catchAll && candidate.ContentType == "text/html" {
+			continue
+		}
+		suggested = candidate.URL
+		break
+	}
+	return candidates, suggested
+}
+
+func (ws *Workspace) discoverHandler(w http.ResponseWriter, r *http.Request) {
+	base, ok := discoveryBase(r.URL.Query().Get("url"))
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, "Неверный адрес сайта")
+		return
+	}
+
+	candidates, suggested := ws.monitor.DiscoverHealth(r.Context(), base)
+	writeJSON(w, map[string]interface{}{
+		"success":    true,
+		"base":       base.String(),
+		"candidates": candidates,
+		"suggested":  suggested,
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Сколько символов тела ответа попадает в уведомление
+const bodySnippetLength = 300
+
+// CheckFailure - подробности неудачной проверки для уведомлений
+type CheckFailure struct {
+	// Строка статуса ответа ("HTTP/1.1 503 Service Unavailable") или текст ошибки запроса
+	StatusLine string `json:"status_line"`
+	// Начало тела ответа, очищенное от управляющих символов и лишних пробелов
+	Snippet string `json:"snippet,omitempty"`
+}
+
+func (f *CheckFailure) String() string {
+	if f.Snippet == "" {
+		return f.StatusLine
+	}
+	return f.StatusLine + ": " + f.Snippet
+}
+
+// sanitizeSnippet превращает начало тела ответа в одну строку, безопасную
+// для syslog и мессенджеров: невалидный UTF-8 и управляющие символы
+// заменяются пробелами, пробелы схлопываются, длина ограничивается
+func sanitizeSnippet(body []byte, limit int) string {
+	text := strings.ToValidUTF8(string(body), " ")
+
+	var b strings.Builder
+	space := false
+	for _, r := range text {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteRune(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+
+	snippet := b.String()
+	if utf8.RuneCountInString(snippet) <= limit {
+		return snippet
+	}
+	return string([]rune(snippet)[:limit]) + "…"
+}
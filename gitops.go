@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultGitOpsInterval = 5 * time.Minute
+
+// GitOpsConfig - репозиторий, из которого синхронизируется список сервисов
+type GitOpsConfig struct {
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+	// Путь к файлу со списком сервисов внутри репозитория
+	Path string `json:"path"`
+	// Приватный SSH-ключ для доступа к репозиторию (необязательно)
+	SSHKey string `json:"ssh_key,omitempty"`
+}
+
+// GitOpsChange - изменение, внесенное синхронизацией
+type GitOpsChange struct {
+	Name   string `json:"name"`
+	Action string `json:"action"` // added, updated или removed
+}
+
+// GitOpsDrift - расхождение между работающей конфигурацией и репозиторием,
+// которое синхронизация не устраняет
+type GitOpsDrift struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"` // unmanaged или conflict
+	Detail string `json:"detail"`
+}
+
+// GitOpsStatus - состояние последней синхронизации. Changes - изменения
+// последней синхронизации, которая что-то изменила
+type GitOpsStatus struct {
+	Repo     string         `json:"repo"`
+	Branch   string         `json:"branch"`
+	Path     string         `json:"path"`
+	Commit   string         `json:"commit,omitempty"`
+	LastSync time.Time      `json:"last_sync,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Changes  []GitOpsChange `json:"changes"`
+	Drift    []GitOpsDrift  `json:"drift"`
+}
+
+// GitOpsSync периодически забирает список сервисов из Git и приводит к нему
+// сервисы монитора, помеченные как управляемые (Managed)
+type GitOpsSync struct {
+	config  GitOpsConfig
+	dir     string
+	monitor *Monitor
+	status  GitOpsStatus
+	mutex   sync.RWMutex
+}
+
+// NewGitOpsSync создает синхронизацию с локальной копией репозитория в dir
+func NewGitOpsSync(config GitOpsConfig, dir string, monitor *Monitor) (*GitOpsSync, error) {
+	if config.Repo == "" {
+		return nil, fmt.Errorf("не указан репозиторий GitOps")
+	}
+	if config.Branch == "" {
+		config.Branch = "main"
+	}
+	// Значения передаются git позиционными аргументами и не должны читаться как его флаги
+	if strings.HasPrefix(config.Repo, "-") {
+		return nil, fmt.Errorf("неверный репозиторий GitOps %q", config.Repo)
+	}
+	if strings.HasPrefix(config.Branch, "-") {
+		return nil, fmt.Errorf("неверная ветка GitOps %q", config.Branch)
+	}
+	if config.Path == "" {
+		config.Path = "services.json"
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("для GitOps нужен git: %v", err)
+	}
+
+	return &GitOpsSync{
+		config:  config,
+		dir:     dir,
+		monitor: monitor,
+		status: GitOpsStatus{
+			Repo:    config.Repo,
+			Branch:  config.Branch,
+			Path:    config.Path,
+			Changes: []GitOpsChange{},
+			Drift:   []GitOpsDrift{},
+		},
+	}, nil
+}
+
+func (g *GitOpsSync) Status() GitOpsStatus {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return g.status
+}
+
+// Run синхронизирует сразу и затем с интервалом до отмены ctx
+func (g *GitOpsSync) Run(ctx context.Context, interval time.Duration) {
+	g.Sync(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.Sync(ctx)
+		}
+	}
+}
+
+// Sync обновляет локальную копию репозитория и применяет список сервисов.
+// При ошибке сервисы остаются такими, какими были после прошлой синхронизации
+func (g *GitOpsSync) Sync(ctx context.Context) {
+	commit, declared, err := g.pull(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Ошибка синхронизации с %s: %v", g.config.Repo, err)
+		g.mutex.Lock()
+		g.status.Error = err.Error()
+		g.status.LastSync = time.Now()
+		g.mutex.Unlock()
+		return
+	}
+
+	changes, conflicts := g.monitor.SyncManaged(declared)
+	for _, change := range changes {
+		fmt.Printf("GitOps (%s): сервис %s %s\n", commit[:7], change.Name, gitOpsActionLabels[change.Action])
+	}
+
+	g.mutex.Lock()
+	g.status.Commit = commit
+	g.status.LastSync = time.Now()
+	g.status.Error = ""
+	if len(changes) > 0 {
+		g.status.Changes = changes
+	}
+	g.status.Drift = append(conflicts, g.monitor.unmanagedDrift(conflicts)...)
+	g.mutex.Unlock()
+}
+
+var gitOpsActionLabels = map[string]string{
+	"added":   "добавлен",
+	"updated": "обновлен",
+	"removed": "удален",
+}
+
+// pull клонирует репозиторий или забирает последний коммит ветки и читает
+// из него список сервисов
+func (g *GitOpsSync) pull(ctx context.Context) (string, []Service, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	if _, err := os.Stat(filepath.Join(g.dir, ".git")); os.IsNotExist(err) {
+		os.RemoveAll(g.dir)
+		if err := os.MkdirAll(filepath.Dir(g.dir), 0755); err != nil {
+			return "", nil, fmt.Errorf("ошибка создания директории %s: %v", g.dir, err)
+		}
+		if _, err := g.git(ctx, "", "clone", "--depth", "1", "--single-branch", "--branch", g.config.Branch, "--", g.config.Repo, g.dir); err != nil {
+			return "", nil, err
+		}
+	} else {
+		if _, err := g.git(ctx, g.dir, "fetch", "--depth", "1", "--", g.config.Repo, g.config.Branch); err != nil {
+			return "", nil, err
+		}
+		if _, err := g.git(ctx, g.dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return "", nil, err
+		}
+	}
+
+	commit, err := g.git(ctx, g.dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", nil, err
+	}
+
+	filename := filepath.Join(g.dir, filepath.FromSlash(g.config.Path))
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", nil, fmt.Errorf("ошибка чтения %s из репозитория: %v", g.config.Path, err)
+	}
+	var declared []Service
+	if err := json.Unmarshal(data, &declared); err != nil {
+		return "", nil, fmt.Errorf("ошибка парсинга JSON из %s: %v", g.config.Path, err)
+	}
+	if err := validateDeclared(declared); err != nil {
+		return "", nil, fmt.Errorf("%s: %v", g.config.Path, err)
+	}
+	return commit, declared, nil
+}
+
+// git выполняет команду git в каталоге dir и возвращает ее вывод
+func (g *GitOpsSync) git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if g.config.SSHKey != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new", shellQuote(g.config.SSHKey)))
+	}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(output.String()))
+	}
+	return strings.TrimSpace(output.String()), nil
+}
+
+// shellQuote заключает строку в одинарные кавычки для sh: GIT_SSH_COMMAND
+// выполняется через оболочку, и путь к ключу может содержать пробелы
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// validateDeclared проверяет список сервисов из репозитория: названия
+// служат ключами синхронизации и должны быть уникальны
+func validateDeclared(declared []Service) error {
+	names := make(map[string]bool)
+	for _, service := range declared {
+		if service.Name == "" || service.URL == "" {
+			return fmt.Errorf("у каждого сервиса должны быть название и URL")
+		}
+		if names[service.Name] {
+			return fmt.Errorf("сервис %q описан повторно", service.Name)
+		}
+		names[service.Name] = true
+	}
+	return nil
+}
+
+// declaredConfig оставляет только поля сервиса, задаваемые в репозитории
+func declaredConfig(s Service) Service {
+	return Service{
+		Name:          s.Name,
+		URL:           s.URL,
+		Group:         s.Group,
+		UserAgent:     s.UserAgent,
+		Cookies:       s.Cookies,
+		Keyword:       s.Keyword,
+		InvertKeyword: s.InvertKeyword,
+		Redirects:     s.Redirects,
+		StartAt:       s.StartAt,
+		StopAt:        s.StopAt,
+	}
+}
+
+func sameConfig(a, b Service) bool {
+	ja, _ := json.Marshal(declaredConfig(a))
+	jb, _ := json.Marshal(declaredConfig(b))
+	return bytes.Equal(ja, jb)
+}
+
+// SyncManaged приводит управляемые сервисы к списку из репозитория:
+// добавляет новые, обновляет настройки измененных (сохраняя состояние
+// и историю) и удаляет исчезнувшие. Сервисы, добавленные вручную, не
+// меняются; совпадение названия с ними возвращается как конфликт
+func (m *Monitor) SyncManaged(declared []Service) ([]GitOpsChange, []GitOpsDrift) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	changes := []GitOpsChange{}
+	conflicts := []GitOpsDrift{}
+
+	wanted := make(map[string]bool)
+	for _, d := range declared {
+		wanted[d.Name] = true
+
+		i := -1
+		for j := range m.services {
+			if m.services[j].Name == d.Name {
+				i = j
+				break
+			}
+		}
+
+		switch {
+		case i < 0:
+			service := declaredConfig(d)
+			service.ID = newServiceID()
+			service.Managed = true
+			m.services = append(m.services, service)
+			changes = append(changes, GitOpsChange{Name: d.Name, Action: "added"})
+		case !m.services[i].Managed:
+			conflicts = append(conflicts, GitOpsDrift{
+				Name:   d.Name,
+				Kind:   "conflict",
+				Detail: "сервис с таким названием добавлен вручную, описание из репозитория не применено",
+			})
+		case !sameConfig(m.services[i], d):
+			config := declaredConfig(d)
+			service := &m.services[i]
+			service.URL = config.URL
+			service.Group = config.Group
+			service.UserAgent = config.UserAgent
+			service.Cookies = config.Cookies
+			service.Keyword = config.Keyword
+			service.InvertKeyword = config.InvertKeyword
+			service.Redirects = config.Redirects
+			service.StartAt = config.StartAt
+			service.StopAt = config.StopAt
+			changes = append(changes, GitOpsChange{Name: d.Name, Action: "updated"})
+		}
+	}
+
+	for i := len(m.services) - 1; i >= 0; i-- {
+		if m.services[i].Managed && !wanted[m.services[i].Name] {
+			changes = append(changes, GitOpsChange{Name: m.services[i].Name, Action: "removed"})
+			m.removeLocked(i)
+		}
+	}
+
+	if len(changes) > 0 {
+		m.saveToFile()
+	}
+	return changes, conflicts
+}
+
+// unmanagedDrift перечисляет сервисы, добавленные вручную в обход репозитория,
+// кроме уже отмеченных как конфликт
+func (m *Monitor) unmanagedDrift(conflicts []GitOpsDrift) []GitOpsDrift {
+	conflicting := make(map[string]bool)
+	for _, conflict := range conflicts {
+		conflicting[conflict.Name] = true
+	}
+
+	var drift []GitOpsDrift
+	for _, service := range m.GetServices() {
+		if !service.Managed && !conflicting[service.Name] {
+			drift = append(drift, GitOpsDrift{
+				Name:   service.Name,
+				Kind:   "unmanaged",
+				Detail: "сервис добавлен вручную и отсутствует в репозитории",
+			})
+		}
+	}
+	return drift
+}
+
+func (ws *Workspace) gitOpsHandler(w http.ResponseWriter, r *http.Request) {
+	if ws.gitops == nil {
+		writeJSON(w, map[string]interface{}{
+			"enabled": false,
+		})
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"enabled": true,
+		"status":  ws.gitops.Status(),
+	})
+}
+
+// EnableGitOps включает синхронизацию сервисов пространства с репозиторием.
+// Локальная копия репозитория хранится в каталоге gitops пространства
+func (ws *Workspace) EnableGitOps(config GitOpsConfig) error {
+	g, err := NewGitOpsSync(config, filepath.Join(ws.dir, "gitops"), ws.monitor)
+	if err != nil {
+		return err
+	}
+	ws.gitops = g
+	return nil
+}
@@ -1,3 +1,8 @@
 module web-monitor
 
-go 1.21
\ No newline at end of file
+go 1.21
+
+require (
+	github.com/andybalholm/brotli v1.1.0
+	golang.org/x/sys v0.20.0
+)
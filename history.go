@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultHistoryRetention = 90 * 24 * time.Hour
+
+// Сколько последних проверок держится в памяти. Этого хватает для страницы
+// сервиса (до 30 дней); более старые периоды отчетов читаются из файла
+const historyMemoryWindow = 31 * 24 * time.Hour
+
+// CheckResult - результат одной проверки сервиса в истории
+type CheckResult struct {
+	ServiceID string        `json:"service_id"`
+	Time      time.Time     `json:"time"`
+	Status    bool          `json:"status"`
+	LatencyMs float64       `json:"latency_ms"`
+	Timings   *CheckTimings `json:"timings,omitempty"`
+}
+
+// HistoryStore дописывает результаты проверок в файл JSON Lines и держит
+// в памяти последние из них. mutex защищает только данные в памяти, а
+// fileMutex - запись файла, чтобы ежечасная перезапись файла не блокировала
+// чтение статистики
+type HistoryStore struct {
+	results     []CheckResult
+	mutex       sync.RWMutex
+	fileMutex   sync.Mutex
+	filename    string
+	retention   time.Duration
+	window      time.Duration
+	lastCompact time.Time
+}
+
+func NewHistoryStore(filename string, retention time.Duration) *HistoryStore {
+	return &HistoryStore{
+		results:   make([]CheckResult, 0),
+		filename:  filename,
+		retention: retention,
+		window:    historyMemoryWindow,
+	}
+}
+
+// memoryCutoff - начало периода, проверки за который есть в памяти
+func (h *HistoryStore) memoryCutoff(now time.Time) time.Time {
+	if h.retention < h.window {
+		return now.Add(-h.retention)
+	}
+	return now.Add(-h.window)
+}
+
+func (h *HistoryStore) LoadFromFile() error {
+	h.fileMutex.Lock()
+	defer h.fileMutex.Unlock()
+
+	// Сначала отбрасываем устаревшие записи, затем читаем в память последние
+	if err := h.compact(); err != nil {
+		return err
+	}
+
+	cutoff := h.memoryCutoff(time.Now())
+	results, err := h.readFile(func(result CheckResult) bool {
+		return result.Time.After(cutoff)
+	})
+	if err != nil {
+		return err
+	}
+
+	h.mutex.Lock()
+	h.results = results
+	h.mutex.Unlock()
+	return nil
+}
+
+// readFile читает из файла истории записи, подходящие под keep
+func (h *HistoryStore) readFile(keep func(CheckResult) bool) ([]CheckResult, error) {
+	results := make([]CheckResult, 0)
+	file, err := os.Open(h.filename)
+	if os.IsNotExist(err) {
+		return results, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла %s: %v", h.filename, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var result CheckResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			// Пропускаем поврежденные строки, например недописанную при аварийной остановке
+			continue
+		}
+		if keep(result) {
+			results = append(results, result)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла %s: %v", h.filename, err)
+	}
+	return results, nil
+}
+
+// Append добавляет результат проверки и раз в час удаляет устаревшие записи
+func (h *HistoryStore) Append(result CheckResult) error {
+	h.mutex.Lock()
+	h.results = append(h.results, result)
+	h.mutex.Unlock()
+
+	h.fileMutex.Lock()
+	defer h.fileMutex.Unlock()
+
+	file, err := os.OpenFile(h.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("ошибка записи в файл %s: %v", h.filename, err)
+	}
+	defer file.Close()
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации в JSON: %v", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("ошибка записи в файл %s: %v", h.filename, err)
+	}
+
+	if time.Since(h.lastCompact) > time.Hour {
+		return h.compact()
+	}
+	return nil
+}
+
+// compact отбрасывает записи старше срока хранения: перезаписывает файл и
+// убирает из памяти проверки, вышедшие из окна. Вызывается под fileMutex
+func (h *HistoryStore) compact() error {
+	now := time.Now()
+	h.lastCompact = now
+
+	memoryCutoff := h.memoryCutoff(now)
+	h.mutex.Lock()
+	kept := make([]CheckResult, 0, len(h.results))
+	for _, result := range h.results {
+		if result.Time.After(memoryCutoff) {
+			kept = append(kept, result)
+		}
+	}
+	h.results = kept
+	h.mutex.Unlock()
+
+	cutoff := now.Add(-h.retention)
+	results, err := h.readFile(func(result CheckResult) bool {
+		return result.Time.After(cutoff)
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp := h.filename + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("ошибка записи в файл %s: %v", tmp, err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, result := range results {
+		if err := encoder.Encode(result); err != nil {
+			file.Close()
+			return fmt.Errorf("ошибка сериализации в JSON: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка записи в файл %s: %v", tmp, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("ошибка записи в файл %s: %v", tmp, err)
+	}
+
+	return os.Rename(tmp, h.filename)
+}
+
+// Results возвращает проверки сервиса в интервале [from, to) в хронологическом
+// порядке. Периоды старше окна в памяти читаются из файла
+func (h *HistoryStore) Results(serviceID string, from, to time.Time) []CheckResult {
+	match := func(result CheckResult) bool {
+		return result.ServiceID == serviceID && !result.Time.Before(from) && result.Time.Before(to)
+	}
+
+	if from.Before(h.memoryCutoff(time.Now())) {
+		results, err := h.readFile(match)
+		if err == nil {
+			return results
+		}
+		log.Printf("Ошибка чтения истории проверок: %v", err)
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	results := make([]CheckResult, 0)
+	for _, result := range h.results {
+		if match(result) {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// LatencyStats - сводка по времени ответа и доступности за период
+type LatencyStats struct {
+	Checks int     `json:"checks"`
+	Uptime float64 `json:"uptime"`
+	AvgMs  float64 `json:"avg_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+}
+
+// ComputeLatencyStats считает доступность по всем проверкам, а перцентили
+// времени ответа - только по успешным, чтобы таймауты не искажали картину
+func ComputeLatencyStats(results []CheckResult) LatencyStats {
+	stats := LatencyStats{Checks: len(results)}
+	if len(results) == 0 {
+		return stats
+	}
+
+	latencies := make([]float64, 0, len(results))
+	sum := 0.0
+	for _, result := range results {
+		if result.Status {
+			latencies = append(latencies, result.LatencyMs)
+			sum += result.LatencyMs
+		}
+	}
+	stats.Uptime = roundTo(float64(len(latencies))/float64(len(results))*100, 2)
+	if len(latencies) == 0 {
+		return stats
+	}
+
+	sort.Float64s(latencies)
+	stats.AvgMs = roundTo(sum/float64(len(latencies)), 1)
+	stats.P50Ms = percentile(latencies, 50)
+	stats.P95Ms = percentile(latencies, 95)
+	stats.P99Ms = percentile(latencies, 99)
+	return stats
+}
+
+// percentile вычисляет перцентиль отсортированной выборки методом ближайшего ранга
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return roundTo(sorted[rank-1], 1)
+}
+
+func roundTo(v float64, digits int) float64 {
+	pow := math.Pow(10, float64(digits))
+	return math.Round(v*pow) / pow
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const uptimeRobotAPI = "https://api.uptimerobot.com/v2/getMonitors"
+
+// importedMonitor - монитор другой системы после преобразования: сервис
+// или причина, по которой его нельзя перенести
+type importedMonitor struct {
+	Name     string
+	Service  *Service
+	Reason   string
+	Interval time.Duration
+}
+
+// ImportSkip - монитор, который не был импортирован
+type ImportSkip struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ImportNote - что изменилось при переносе импортированного монитора
+type ImportNote struct {
+	Name string `json:"name"`
+	Note string `json:"note"`
+}
+
+// ImportResult - итог импорта
+type ImportResult struct {
+	Imported []string     `json:"imported"`
+	Skipped  []ImportSkip `json:"skipped"`
+	Notes    []ImportNote `json:"notes"`
+}
+
+// flexBool принимает булевы значения как true/false и как 1/0:
+// разные версии Uptime Kuma сохраняют их по-разному
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null", "":
+		*b = false
+	default:
+		return fmt.Errorf("неверное булево значение %s", data)
+	}
+	return nil
+}
+
+// flexInt принимает числа и строки с числами, null считается нулем
+type flexInt int
+
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" || s == "" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("неверное число %s", data)
+	}
+	*n = flexInt(v)
+	return nil
+}
+
+type kumaBackup struct {
+	MonitorList []kumaMonitor `json:"monitorList"`
+}
+
+type kumaMonitor struct {
+	ID            int      `json:"id"`
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	URL           string   `json:"url"`
+	Method        string   `json:"method"`
+	Keyword       string   `json:"keyword"`
+	InvertKeyword flexBool `json:"invertKeyword"`
+	Active        flexBool `json:"active"`
+	Parent        *int     `json:"parent"`
+	Interval      flexInt  `json:"interval"`
+}
+
+// convertKumaBackup преобразует мониторы из резервной копии Uptime Kuma
+// (Настройки → Резервное копирование → Экспорт). Переносятся мониторы типов
+// http и keyword, группы Kuma становятся группами сервисов
+func convertKumaBackup(data []byte) ([]importedMonitor, error) {
+	var backup kumaBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("ошибка разбора резервной копии Uptime Kuma: %v", err)
+	}
+	if backup.MonitorList == nil {
+		return nil, fmt.Errorf("в резервной копии Uptime Kuma нет списка мониторов (monitorList)")
+	}
+
+	groups := make(map[int]string)
+	for _, monitor := range backup.MonitorList {
+		if monitor.Type == "group" {
+			groups[monitor.ID] = monitor.Name
+		}
+	}
+
+	var monitors []importedMonitor
+	for _, monitor := range backup.MonitorList {
+		item := importedMonitor{Name: monitor.Name, Interval: time.Duration(monitor.Interval) * time.Second}
+		switch {
+		case monitor.Type == "group":
+			continue
+		case monitor.Type != "http" && monitor.Type != "keyword":
+			item.Reason = fmt.Sprintf("тип %q не поддерживается, переносятся только HTTP-проверки", monitor.Type)
+		case !bool(monitor.Active):
+			item.Reason = "монитор отключен"
+		case monitor.Method != "" && !strings.EqualFold(monitor.Method, http.MethodGet):
+			item.Reason = fmt.Sprintf("метод %s не поддерживается, проверки выполняются методом GET", monitor.Method)
+		default:
+			service := Service{Name: monitor.Name, URL: monitor.URL}
+			if monitor.Parent != nil {
+				service.Group = groups[*monitor.Parent]
+			}
+			if monitor.Type == "keyword" {
+				service.Keyword = monitor.Keyword
+				service.InvertKeyword = bool(monitor.InvertKeyword)
+			}
+			item.Service = &service
+		}
+		monitors = append(monitors, item)
+	}
+	return monitors, nil
+}
+
+type uptimeRobotResponse struct {
+	Stat  string `json:"stat"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Pagination struct {
+		Offset int `json:"offset"`
+		Limit  int `json:"limit"`
+		Total  int `json:"total"`
+	} `json:"pagination"`
+	Monitors []uptimeRobotMonitor `json:"monitors"`
+}
+
+type uptimeRobotMonitor struct {
+	FriendlyName string  `json:"friendly_name"`
+	URL          string  `json:"url"`
+	Type         flexInt `json:"type"`
+	KeywordType  flexInt `json:"keyword_type"`
+	KeywordValue string  `json:"keyword_value"`
+	Status       flexInt `json:"status"`
+	Interval     flexInt `json:"interval"`
+}
+
+// Типы мониторов и условия ключевых слов UptimeRobot API v2
+const (
+	uptimeRobotTypeHTTP    = 1
+	uptimeRobotTypeKeyword = 2
+	// keyword_type задает условие тревоги: "слово есть" или "слова нет"
+	uptimeRobotKeywordExists    = 1
+	uptimeRobotKeywordNotExists = 2
+	uptimeRobotStatusPaused     = 0
+)
+
+var uptimeRobotTypeNames = map[int]string{3: "ping", 4: "port", 5: "heartbeat"}
+
+// parseUptimeRobot разбирает ответ getMonitors UptimeRobot API v2
+// (он же сохраненный экспорт мониторов)
+func parseUptimeRobot(data []byte) (uptimeRobotResponse, error) {
+	var resp uptimeRobotResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return resp, fmt.Errorf("ошибка разбора ответа UptimeRobot: %v", err)
+	}
+	if resp.Stat == "fail" {
+		if resp.Error != nil {
+			return resp, fmt.Errorf("ошибка UptimeRobot: %s", resp.Error.Message)
+		}
+		return resp, fmt.Errorf("ошибка UptimeRobot")
+	}
+	if resp.Monitors == nil {
+		return resp, fmt.Errorf("в ответе UptimeRobot нет списка мониторов (monitors)")
+	}
+	return resp, nil
+}
+
+// convertUptimeRobot преобразует мониторы UptimeRobot. Переносятся HTTP(S)
+// и keyword-мониторы
+func convertUptimeRobot(list []uptimeRobotMonitor) []importedMonitor {
+	var monitors []importedMonitor
+	for _, monitor := range list {
+		item := importedMonitor{Name: monitor.FriendlyName, Interval: time.Duration(monitor.Interval) * time.Second}
+		switch {
+		case monitor.Type != uptimeRobotTypeHTTP && monitor.Type != uptimeRobotTypeKeyword:
+			name := uptimeRobotTypeNames[int(monitor.Type)]
+			if name == "" {
+				name = strconv.Itoa(int(monitor.Type))
+			}
+			item.Reason = fmt.Sprintf("тип %q не поддерживается, переносятся только HTTP-проверки", name)
+		case monitor.Status == uptimeRobotStatusPaused:
+			item.Reason = "монитор приостановлен"
+		default:
+			service := Service{Name: monitor.FriendlyName, URL: monitor.URL}
+			if monitor.Type == uptimeRobotTypeKeyword {
+				service.Keyword = monitor.KeywordValue
+				service.InvertKeyword = monitor.KeywordType == uptimeRobotKeywordExists
+			}
+			item.Service = &service
+		}
+		monitors = append(monitors, item)
+	}
+	return monitors
+}
+
+// fetchUptimeRobot загружает все мониторы аккаунта через API постранично
+func fetchUptimeRobot(ctx context.Context, client *http.Client, apiKey string) ([]uptimeRobotMonitor, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	var monitors []uptimeRobotMonitor
+	for {
+		form := url.Values{
+			"api_key": {apiKey},
+			"format":  {"json"},
+			"offset":  {strconv.Itoa(len(monitors))},
+			"limit":   {"50"},
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, uptimeRobotAPI, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка запроса к UptimeRobot: %v", err)
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения ответа UptimeRobot: %v", err)
+		}
+
+		page, err := parseUptimeRobot(data)
+		if err != nil {
+			return nil, err
+		}
+		monitors = append(monitors, page.Monitors...)
+		if len(page.Monitors) == 0 || len(monitors) >= page.Pagination.Total {
+			return monitors, nil
+		}
+	}
+}
+
+// ImportServices добавляет преобразованные мониторы. Сервисы с уже
+// отслеживаемым адресом пропускаются, чтобы повторный импорт не создавал дублей.
+// Своего интервала у сервиса нет, поэтому мониторы с интервалом, отличным
+// от общего, перечисляются в заметках с исходным значением
+func (m *Monitor) ImportServices(monitors []importedMonitor) ImportResult {
+	result := ImportResult{Imported: []string{}, Skipped: []ImportSkip{}, Notes: []ImportNote{}}
+
+	known := make(map[string]bool)
+	for _, service := range m.GetServices() {
+		known[service.URL] = true
+	}
+
+	for _, monitor := range monitors {
+		switch {
+		case monitor.Service == nil:
+			result.Skipped = append(result.Skipped, ImportSkip{Name: monitor.Name, Reason: monitor.Reason})
+		case monitor.Service.URL == "":
+			result.Skipped = append(result.Skipped, ImportSkip{Name: monitor.Name, Reason: "не указан URL"})
+		case known[monitor.Service.URL]:
+			result.Skipped = append(result.Skipped, ImportSkip{Name: monitor.Name, Reason: "сервис с таким URL уже есть"})
+		default:
+			m.AddService(*monitor.Service)
+			known[monitor.Service.URL] = true
+			result.Imported = append(result.Imported, monitor.Name)
+			if monitor.Interval > 0 && monitor.Interval != m.interval {
+				result.Notes = append(result.Notes, ImportNote{
+					Name: monitor.Name,
+					Note: fmt.Sprintf("интервал проверки %s не перенесен, сервис проверяется с общим интервалом %s", monitor.Interval, m.interval),
+				})
+			}
+		}
+	}
+	return result
+}
+
+func (ws *Workspace) importHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		// uptime-kuma или uptimerobot
+		Source string `json:"source"`
+		// Содержимое резервной копии Kuma или сохраненный ответ UptimeRobot
+		Data json.RawMessage `json:"data"`
+		// Ключ UptimeRobot API, если данные нужно загрузить из аккаунта
+		APIKey string `json:"api_key"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 50*1024*1024)).Decode(&req); err != nil {
+		writeError(w, "Неверный формат данных")
+		return
+	}
+
+	var monitors []importedMonitor
+	var err error
+	switch req.Source {
+	case "uptime-kuma":
+		if len(req.Data) == 0 {
+			writeError(w, "Нужна резервная копия Uptime Kuma")
+			return
+		}
+		monitors, err = convertKumaBackup(req.Data)
+	case "uptimerobot":
+		var list []uptimeRobotMonitor
+		switch {
+		case req.APIKey != "":
+			list, err = fetchUptimeRobot(r.Context(), ws.monitor.client, req.APIKey)
+		case len(req.Data) > 0:
+			var resp uptimeRobotResponse
+			resp, err = parseUptimeRobot(req.Data)
+			list = resp.Monitors
+		default:
+			writeError(w, "Нужен ключ UptimeRobot API или экспорт мониторов")
+			return
+		}
+		monitors = convertUptimeRobot(list)
+	default:
+		writeError(w, "Неизвестный источник импорта (поддерживаются uptime-kuma и uptimerobot)")
+		return
+	}
+	if err != nil {
+		writeError(w, err.Error())
+		return
+	}
+
+	result := ws.monitor.ImportServices(monitors)
+	writeJSON(w, map[string]interface{}{
+		"success":  true,
+		"imported": result.Imported,
+		"skipped":  result.Skipped,
+		"notes":    result.Notes,
+	})
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Допустимые статусы инцидента
+var incidentStatuses = map[string]bool{
+	"investigating": true,
+	"identified":    true,
+	"resolved":      true,
+}
+
+type IncidentUpdate struct {
+	Status  string    `json:"status"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Incident - запись об инциденте, которую администратор публикует на странице статуса
+type Incident struct {
+	ID        int              `json:"id"`
+	Title     string           `json:"title"`
+	Status    string           `json:"status"`
+	Services  []string         `json:"services"`
+	Updates   []IncidentUpdate `json:"updates"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+type IncidentStore struct {
+	incidents []Incident
+	mutex     sync.RWMutex
+	filename  string
+}
+
+func NewIncidentStore(filename string) *IncidentStore {
+	return &IncidentStore{
+		incidents: make([]Incident, 0),
+		filename:  filename,
+	}
+}
+
+func (s *IncidentStore) LoadFromFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла %s: %v", s.filename, err)
+	}
+
+	if err := json.Unmarshal(data, &s.incidents); err != nil {
+		return fmt.Errorf("ошибка парсинга JSON из файла %s: %v", s.filename, err)
+	}
+	return nil
+}
+
+func (s *IncidentStore) saveToFile() error {
+	data, err := json.MarshalIndent(s.incidents, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации в JSON: %v", err)
+	}
+
+	if err := ioutil.WriteFile(s.filename, data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи в файл %s: %v", s.filename, err)
+	}
+	return nil
+}
+
+// GetIncidents возвращает инциденты, начиная с самых свежих
+func (s *IncidentStore) GetIncidents() []Incident {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	list := make([]Incident, len(s.incidents))
+	copy(list, s.incidents)
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+func (s *IncidentStore) Create(title, status string, services []string, message string) Incident {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := 1
+	for _, inc := range s.incidents {
+		if inc.ID >= id {
+			id = inc.ID + 1
+		}
+	}
+
+	now := time.Now()
+	if services == nil {
+		services = []string{}
+	}
+	incident := Incident{
+		ID:        id,
+		Title:     title,
+		Status:    status,
+		Services:  services,
+		Updates:   []IncidentUpdate{{Status: status, Message: message, Time: now}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.incidents = append(s.incidents, incident)
+	s.saveToFile()
+	return incident
+}
+
+// AddUpdate добавляет запись в хронологию инцидента и меняет его статус
+func (s *IncidentStore) AddUpdate(id int, status, message string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.incidents {
+		if s.incidents[i].ID == id {
+			now := time.Now()
+			s.incidents[i].Status = status
+			s.incidents[i].UpdatedAt = now
+			s.incidents[i].Updates = append(s.incidents[i].Updates, IncidentUpdate{
+				Status:  status,
+				Message: message,
+				Time:    now,
+			})
+			s.saveToFile()
+			return true
+		}
+	}
+	return false
+}
+
+func (s *IncidentStore) Remove(id int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.incidents {
+		if s.incidents[i].ID == id {
+			s.incidents = append(s.incidents[:i], s.incidents[i+1:]...)
+			s.saveToFile()
+			return true
+		}
+	}
+	return false
+}
+
+func (ws *Workspace) incidentsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, ws.incidents.GetIncidents())
+}
+
+func (ws *Workspace) addIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Title    string   `json:"title"`
+		Status   string   `json:"status"`
+		Services []string `json:"services"`
+		Message  string   `json:"message"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Неверный формат данных")
+		return
+	}
+
+	if req.Title == "" || req.Message == "" {
+		writeError(w, "Заголовок и описание обязательны")
+		return
+	}
+	if req.Status == "" {
+		req.Status = "investigating"
+	}
+	if !incidentStatuses[req.Status] {
+		writeError(w, "Неверный статус инцидента")
+		return
+	}
+
+	incident := ws.incidents.Create(req.Title, req.Status, req.Services, req.Message)
+
+	writeJSON(w, map[string]interface{}{
+		"success":  true,
+		"incident": incident,
+	})
+}
+
+func (ws *Workspace) updateIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID      int    `json:"id"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Неверный формат данных")
+		return
+	}
+
+	if req.Message == "" {
+		writeError(w, "Текст обновления обязателен")
+		return
+	}
+	if !incidentStatuses[req.Status] {
+		writeError(w, "Неверный статус инцидента")
+		return
+	}
+
+	if !ws.incidents.AddUpdate(req.ID, req.Status, req.Message) {
+		writeError(w, "Инцидент не найден")
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"success": true,
+	})
+}
+
+func (ws *Workspace) removeIncidentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Неверный формат данных")
+		return
+	}
+
+	if !ws.incidents.Remove(req.ID) {
+		writeError(w, "Инцидент не найден")
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"success": true,
+	})
+}
@@ -1,62 +1,185 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
 type Service struct {
-	Name   string `json:"name"`
-	URL    string `json:"url"`
-	Status bool   `json:"status"`
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	URL           string            `json:"url"`
+	Group         string            `json:"group,omitempty"`
+	UserAgent     string            `json:"user_agent,omitempty"`
+	Cookies       map[string]string `json:"cookies,omitempty"`
+	Keyword       string            `json:"keyword,omitempty"`
+	InvertKeyword bool              `json:"invert_keyword,omitempty"`
+	Redirects     []string          `json:"expected_redirects,omitempty"`
+	StartAt       *time.Time        `json:"start_at,omitempty"`
+	StopAt        *time.Time        `json:"stop_at,omitempty"`
+	Status        bool              `json:"status"`
+	LastCheck     *time.Time        `json:"last_check,omitempty"`
+	LatencyMs     float64           `json:"latency_ms"`
+	Timings       *CheckTimings     `json:"timings,omitempty"`
+	DownSince     *time.Time        `json:"down_since,omitempty"`
+	PausedAt      *time.Time        `json:"paused_at,omitempty"`
+	Maintenance   *Maintenance      `json:"maintenance,omitempty"`
+	Managed       bool              `json:"managed,omitempty"`
+}
+
+// Monitored сообщает, входит ли момент now в окно мониторинга сервиса:
+// до StartAt и начиная со StopAt сервис не проверяется
+func (s Service) Monitored(now time.Time) bool {
+	if s.StartAt != nil && now.Before(*s.StartAt) {
+		return false
+	}
+	if s.StopAt != nil && !now.Before(*s.StopAt) {
+		return false
+	}
+	return true
+}
+
+// serviceView - сервис в ответах API. Cookies часто содержат токены сессий,
+// поэтому их значения остаются только в services.json, а наружу отдаются имена
+type serviceView struct {
+	Service
+	Cookies []string `json:"cookies,omitempty"`
+}
+
+func newServiceView(service Service) serviceView {
+	view := serviceView{Service: service}
+	for name := range service.Cookies {
+		view.Cookies = append(view.Cookies, name)
+	}
+	sort.Strings(view.Cookies)
+	return view
+}
+
+func newServiceViews(services []Service) []serviceView {
+	views := make([]serviceView, len(services))
+	for i, service := range services {
+		views[i] = newServiceView(service)
+	}
+	return views
 }
 
 type Monitor struct {
 	services []Service
 	mutex    sync.RWMutex
 	filename string
+	metrics  *MetricsEmitter
+	// Пространство, к которому относятся метрики (пусто для пространства по умолчанию)
+	metricsScope string
+	notifiers    []Notifier
+	history      *HistoryStore
+	// Интервал фоновых проверок
+	interval time.Duration
+	// Таймаут одной проверки
+	timeout time.Duration
+	// HTTP-клиент проверок, общий для всех пространств
+	client *http.Client
+	// User-Agent проверок, если у сервиса не задан свой
+	userAgent string
+	// Контексты, отменяемые при удалении сервиса, по его идентификатору
+	lifetimes map[string]serviceLifetime
+	// Выполняющийся полный проход проверок: ручной запуск во время прохода
+	// дожидается его, а не запускает следующий
+	pass      chan struct{}
+	passMutex sync.Mutex
+	autoPause AutoPausePolicy
+}
+
+type serviceLifetime struct {
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
+const (
+	defaultCheckInterval = 30 * time.Second
+	defaultCheckTimeout  = 10 * time.Second
+)
+
+// Стандартный User-Agent Go часто блокируется как бот, поэтому представляемся явно
+const defaultUserAgent = "Mozilla/5.0 (compatible; web-monitor/1.0)"
+
 func NewMonitor(filename string) *Monitor {
 	return &Monitor{
-		services: make([]Service, 0),
-		filename: filename,
+		services:  make([]Service, 0),
+		filename:  filename,
+		interval:  defaultCheckInterval,
+		timeout:   defaultCheckTimeout,
+		client:    NewCheckClient(defaultTransportOptions),
+		userAgent: defaultUserAgent,
+		lifetimes: make(map[string]serviceLifetime),
+		autoPause: AutoPausePolicy{Interval: defaultPausedInterval, DigestHour: 9},
 	}
 }
 
-func (m *Monitor) AddService(name, url string) {
+// AddService добавляет сервис с новым идентификатором и сбрасывает его состояние
+func (m *Monitor) AddService(service Service) Service {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	
-	service := Service{
-		Name:   name,
-		URL:    url,
-		Status: false,
-	}
+	service.ID = newServiceID()
+	service.Status = false
+	service.LastCheck = nil
+	service.LatencyMs = 0
+	service.Timings = nil
+	service.DownSince = nil
+	service.PausedAt = nil
+	service.Maintenance = nil
+	service.Managed = false
 	m.services = append(m.services, service)
 	m.saveToFile()
+	return service
 }
 
-func (m *Monitor) RemoveService(index int) bool {
+// RemoveService удаляет сервис по идентификатору. Сервисы из Git проверяются
+// под той же блокировкой, что и удаление: синхронизация GitOps может менять
+// список в любой момент
+func (m *Monitor) RemoveService(id string) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	
-	if index < 0 || index >= len(m.services) {
-		return false
+	for i := range m.services {
+		if m.services[i].ID != id {
+			continue
+		}
+		if m.services[i].Managed {
+			return fmt.Errorf("Сервис управляется из Git, удалите его в репозитории")
+		}
+		m.removeLocked(i)
+		m.saveToFile()
+		return nil
+	}
+	return fmt.Errorf("Сервис не найден")
+}
+
+// removeLocked удаляет сервис и прерывает его выполняющиеся проверки.
+// Вызывается под блокировкой монитора
+func (m *Monitor) removeLocked(index int) {
+	if lifetime, ok := m.lifetimes[m.services[index].ID]; ok {
+		lifetime.cancel()
+		delete(m.lifetimes, m.services[index].ID)
 	}
 	
 	// Удаляем элемент из слайса
 	m.services = append(m.services[:index], m.services[index+1:]...)
-	m.saveToFile()
-	return true
 }
 
 func (m *Monitor) LoadFromFile() error {
@@ -80,6 +203,22 @@ func (m *Monitor) LoadFromFile() error {
 		return fmt.Errorf("ошибка парсинга JSON из файла %s: %v", m.filename, err)
 	}
 	
+	// Сервисам из старых версий файла назначаем постоянные идентификаторы.
+	// Старые версии записывали нулевое время для непроверенных сервисов
+	assigned := false
+	for i := range m.services {
+		if m.services[i].LastCheck != nil && m.services[i].LastCheck.IsZero() {
+			m.services[i].LastCheck = nil
+		}
+		if m.services[i].ID == "" {
+			m.services[i].ID = newServiceID()
+			assigned = true
+		}
+	}
+	if assigned {
+		m.saveToFile()
+	}
+	
 	fmt.Printf("Загружено %d сервисов из файла %s\n", len(m.services), m.filename)
 	return nil
 }
@@ -108,28 +247,334 @@ func (m *Monitor) GetServices() []Service {
 	return services
 }
 
-func (m *Monitor) CheckService(url string) bool {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+func (m *Monitor) GetService(id string) (Service, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
+	for _, service := range m.services {
+		if service.ID == id {
+			return service, true
+		}
+	}
+	return Service{}, false
+}
+
+// CheckService выполняет запрос к сервису. При неудаче возвращает строку
+// статуса и начало тела ответа для уведомлений
+func (m *Monitor) CheckService(ctx context.Context, service Service) (bool, *CheckFailure) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, service.URL, nil)
+	if err != nil {
+		return false, &CheckFailure{StatusLine: err.Error()}
+	}
+	
+	userAgent := m.userAgent
+	if service.UserAgent != "" {
+		userAgent = service.UserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for name, value := range service.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
 	}
 	
-	resp, err := client.Get(url)
+	var recorder *redirectRecorder
+	if len(service.Redirects) > 0 {
+		var recordCtx context.Context
+		recordCtx, recorder = withRedirectRecorder(ctx)
+		req = req.WithContext(recordCtx)
+	}
+	
+	resp, err := m.client.Do(req)
 	if err != nil {
-		return false
+		return false, &CheckFailure{StatusLine: err.Error()}
 	}
 	defer resp.Body.Close()
 	
-	return resp.StatusCode == http.StatusOK
+	if recorder != nil {
+		if failure := checkRedirectChain(resp, recorder.chain, service.Redirects); failure != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			return false, failure
+		}
+	}
+	
+	if resp.StatusCode == http.StatusOK && service.Keyword != "" {
+		return checkKeyword(resp, service)
+	}
+	if resp.StatusCode == http.StatusOK {
+		// Дочитываем начало ответа, чтобы соединение можно было вернуть в пул
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		return true, nil
+	}
+	
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4*1024))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	return false, &CheckFailure{
+		StatusLine: resp.Proto + " " + resp.Status,
+		Snippet:    sanitizeSnippet(body, bodySnippetLength),
+	}
+}
+
+// checkKeyword проверяет наличие (или, с InvertKeyword, отсутствие)
+// ключевого слова в первом мегабайте ответа
+func checkKeyword(resp *http.Response, service Service) (bool, *CheckFailure) {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	found := strings.Contains(string(body), service.Keyword)
+	if found != service.InvertKeyword {
+		return true, nil
+	}
+	
+	failure := &CheckFailure{StatusLine: resp.Proto + " " + resp.Status}
+	if service.InvertKeyword {
+		failure.Snippet = fmt.Sprintf("в ответе найдено ключевое слово %q", service.Keyword)
+	} else {
+		failure.Snippet = fmt.Sprintf("в ответе нет ключевого слова %q", service.Keyword)
+	}
+	return false, failure
+}
+
+// CheckAllServices проверяет все сервисы параллельно. Сами проверки идут без
+// блокировки монитора, чтобы чтение списка не ждало самый медленный сервис.
+// Если проход уже выполняется (планировщик или другой ручной запуск), вызов
+// дожидается его результатов вместо нового прохода
+func (m *Monitor) CheckAllServices(ctx context.Context) {
+	m.passMutex.Lock()
+	if pass := m.pass; pass != nil {
+		m.passMutex.Unlock()
+		select {
+		case <-pass:
+		case <-ctx.Done():
+		}
+		return
+	}
+	pass := make(chan struct{})
+	m.pass = pass
+	m.passMutex.Unlock()
+	defer func() {
+		m.passMutex.Lock()
+		m.pass = nil
+		m.passMutex.Unlock()
+		close(pass)
+	}()
+	
+	// Сервисы вне окна мониторинга не проверяем, чтобы до запуска они не считались упавшими,
+	// а приостановленные проверяем реже
+	now := time.Now()
+	var services []Service
+	for _, service := range m.GetServices() {
+		if m.dueForCheck(service, now) {
+			services = append(services, service)
+		}
+	}
+	outcomes := make([]checkOutcome, len(services))
+	
+	var wg sync.WaitGroup
+	for i := range services {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outcomes[i] = m.runCheck(ctx, services[i])
+		}(i)
+	}
+	wg.Wait()
+	
+	m.mutex.Lock()
+	var records []checkRecord
+	for _, outcome := range outcomes {
+		if record, ok := m.recordResult(outcome); ok {
+			records = append(records, record)
+		}
+	}
+	m.mutex.Unlock()
+	
+	m.publish(records)
+}
+
+// CheckServiceNow немедленно проверяет один сервис и возвращает его новое состояние
+func (m *Monitor) CheckServiceNow(ctx context.Context, id string) (Service, bool) {
+	service, ok := m.GetService(id)
+	if !ok {
+		return Service{}, false
+	}
+	
+	outcome := m.runCheck(ctx, service)
+	
+	m.mutex.Lock()
+	record, ok := m.recordResult(outcome)
+	if !ok {
+		m.mutex.Unlock()
+		return Service{}, false
+	}
+	service = m.services[record.index]
+	m.mutex.Unlock()
+	
+	m.publish([]checkRecord{record})
+	return service, true
+}
+
+// RunScheduler периодически проверяет все сервисы до отмены ctx
+func (m *Monitor) RunScheduler(ctx context.Context, interval time.Duration) {
+	m.CheckAllServices(ctx)
+	
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.CheckAllServices(ctx)
+		}
+	}
+}
+
+// checkOutcome - результат проверки, еще не записанный в состояние монитора
+type checkOutcome struct {
+	serviceID string
+	status    bool
+	start     time.Time
+	latency   time.Duration
+	// Проверка прервана остановкой сервера, отменой запроса или удалением сервиса
+	cancelled bool
+	failure   *CheckFailure
+	timings   *CheckTimings
 }
 
-func (m *Monitor) CheckAllServices() {
+// serviceContext возвращает контекст, который отменяется при удалении сервиса
+func (m *Monitor) serviceContext(id string) (context.Context, bool) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	
-	for i := range m.services {
-		status := m.CheckService(m.services[i].URL)
-		m.services[i].Status = status
+	if lifetime, ok := m.lifetimes[id]; ok {
+		return lifetime.ctx, true
+	}
+	for _, service := range m.services {
+		if service.ID == id {
+			ctx, cancel := context.WithCancel(context.Background())
+			m.lifetimes[id] = serviceLifetime{ctx: ctx, cancel: cancel}
+			return ctx, true
+		}
 	}
+	return nil, false
+}
+
+// runCheck проверяет сервис с таймаутом монитора. Проверка прерывается при
+// отмене ctx или удалении сервиса
+func (m *Monitor) runCheck(ctx context.Context, service Service) checkOutcome {
+	outcome := checkOutcome{serviceID: service.ID}
+	
+	lifetime, ok := m.serviceContext(service.ID)
+	if !ok {
+		outcome.cancelled = true
+		return outcome
+	}
+	
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	stop := context.AfterFunc(lifetime, cancel)
+	defer stop()
+	
+	ctx, trace := withCheckTrace(ctx)
+	outcome.start = time.Now()
+	outcome.status, outcome.failure = m.CheckService(ctx, service)
+	outcome.latency = time.Since(outcome.start)
+	outcome.timings = trace.Timings()
+	
+	// Истекший таймаут - это недоступность сервиса, а отмена - нет
+	outcome.cancelled = ctx.Err() == context.Canceled
+	return outcome
+}
+
+// checkRecord - результат проверки, уже записанный в состояние сервиса.
+// Историю, метрики и уведомления publish отправляет после снятия блокировки
+type checkRecord struct {
+	index   int
+	name    string
+	latency time.Duration
+	result  CheckResult
+	event   *StateChange
+}
+
+// recordResult сохраняет результат проверки в состоянии сервиса и возвращает
+// его вместе с событием смены состояния, если оно произошло. Прерванные
+// проверки и результаты удаленных сервисов отбрасываются.
+// Вызывается под блокировкой монитора
+func (m *Monitor) recordResult(outcome checkOutcome) (checkRecord, bool) {
+	if outcome.cancelled {
+		return checkRecord{}, false
+	}
+	
+	i := -1
+	for j := range m.services {
+		if m.services[j].ID == outcome.serviceID {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return checkRecord{}, false
+	}
+	
+	status := outcome.status
+	start := outcome.start
+	latencyMs := roundTo(float64(outcome.latency)/float64(time.Millisecond), 1)
+	
+	// Первая проверка только фиксирует состояние, уведомляем о последующих изменениях.
+	// Проверки, завершившиеся уже во время обслуживания, не уведомляют
+	var event *StateChange
+	if m.services[i].LastCheck != nil && m.services[i].Status != status && !m.services[i].Maintenance.Active(start) {
+		event = &StateChange{
+			Service:  m.services[i],
+			Previous: m.services[i].Status,
+			Current:  status,
+			Time:     start,
+			Failure:  outcome.failure,
+		}
+	}
+	m.services[i].Status = status
+	m.services[i].LastCheck = &start
+	m.services[i].LatencyMs = latencyMs
+	m.services[i].Timings = outcome.timings
+	m.updatePause(i, status, start)
+	
+	return checkRecord{
+		index:   i,
+		name:    m.services[i].Name,
+		latency: outcome.latency,
+		result: CheckResult{
+			ServiceID: m.services[i].ID,
+			Time:      start,
+			Status:    status,
+			LatencyMs: latencyMs,
+			Timings:   outcome.timings,
+		},
+		event: event,
+	}, true
+}
+
+// publish отправляет метрики, дописывает историю и рассылает уведомления.
+// Вызывается без блокировки монитора: запись файла истории и отправка по сети
+// не должны задерживать чтение списка сервисов
+func (m *Monitor) publish(records []checkRecord) {
+	var events []StateChange
+	for _, record := range records {
+		if m.metrics != nil {
+			m.metrics.Emit(m.metricsScope, record.name, record.result.Status, record.latency)
+		}
+		if m.history != nil {
+			if err := m.history.Append(record.result); err != nil {
+				log.Printf("Ошибка сохранения истории проверок: %v", err)
+			}
+		}
+		if record.event != nil {
+			events = append(events, *record.event)
+		}
+	}
+	m.notify(events)
+}
+
+func newServiceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
 }
 
 func getServicesFilePath() string {
@@ -141,55 +586,320 @@ func getServicesFilePath() string {
 	return "services.json"
 }
 
-var monitor *Monitor
-
 func main() {
+	// Код выхода при ошибке сервера: выставляется перед возвратом, чтобы
+	// сначала выполнились отложенные действия (pid-файл, остановка службы)
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+	
 	// Определяем флаг для порта
 	port := flag.String("port", "", "Порт для запуска сервера (обязательный параметр)")
+	metricsAddr := flag.String("metrics-addr", "", "Адрес Graphite/StatsD для отправки метрик (host:port)")
+	metricsProtocol := flag.String("metrics-protocol", "graphite", "Протокол метрик: graphite или statsd")
+	metricsNetwork := flag.String("metrics-network", "udp", "Транспорт метрик: udp или tcp")
+	metricsPrefix := flag.String("metrics-prefix", "web_monitor", "Префикс имен метрик")
+	syslogTarget := flag.String("syslog", "", "Отправка событий в syslog: local, udp://host:port или tcp://host:port")
+	syslogFacility := flag.String("syslog-facility", "daemon", "Facility syslog (daemon, user, local0..local7 и т.д.)")
+	syslogSeverityDown := flag.String("syslog-severity-down", "err", "Уровень syslog для падения сервиса")
+	syslogSeverityUp := flag.String("syslog-severity-up", "notice", "Уровень syslog для восстановления сервиса")
+	historyDays := flag.Int("history-days", 90, "Сколько дней хранить историю проверок")
+	interval := flag.Duration("interval", defaultCheckInterval, "Интервал фоновой проверки сервисов")
+	timeout := flag.Duration("timeout", defaultCheckTimeout, "Таймаут проверки одного сервиса")
+	connections := flag.String("connections", "reuse", "Соединения проверок: reuse (пул соединений) или fresh (новое соединение на каждую проверку)")
+	tlsHandshakeTimeout := flag.Duration("tls-handshake-timeout", defaultTransportOptions.TLSHandshakeTimeout, "Таймаут TLS-рукопожатия")
+	userAgent := flag.String("user-agent", defaultUserAgent, "User-Agent запросов проверки (у сервиса может быть свой)")
+	responseHeaderTimeout := flag.Duration("response-header-timeout", 0, "Таймаут ожидания заголовков ответа (0 - ограничен только -timeout)")
+	adminUser := flag.String("admin-user", "", "Имя администратора пространства по умолчанию (пусто - редактирование доступно всем)")
+	adminPassword := flag.String("admin-password", "", "Пароль администратора пространства по умолчанию")
+	webhookToken := flag.String("webhook-token", "", "Токен входящих вебхуков режима обслуживания (пусто - вебхуки отключены)")
+	gitopsRepo := flag.String("gitops-repo", "", "Git-репозиторий со списком сервисов (пусто - GitOps отключен)")
+	gitopsBranch := flag.String("gitops-branch", "main", "Ветка репозитория GitOps")
+	gitopsPath := flag.String("gitops-path", "services.json", "Путь к списку сервисов в репозитории GitOps")
+	gitopsSSHKey := flag.String("gitops-ssh-key", "", "Приватный SSH-ключ для репозитория GitOps")
+	gitopsInterval := flag.Duration("gitops-interval", defaultGitOpsInterval, "Интервал синхронизации с репозиторием GitOps")
+	autoPauseAfter := flag.Duration("auto-pause-after", 0, "Приостанавливать сервисы, недоступные дольше этого времени (0 - не приостанавливать)")
+	pausedInterval := flag.Duration("paused-interval", defaultPausedInterval, "Интервал проверки приостановленных сервисов")
+	digestTime := flag.String("digest-time", "09:00", "Время ежедневной сводки о приостановленных сервисах (ЧЧ:ММ)")
+	refreshInterval := flag.Duration("refresh-interval", time.Duration(defaultDisplaySettings.RefreshInterval)*time.Second, "Интервал автообновления главной страницы по умолчанию")
+	defaultSort := flag.String("default-sort", defaultDisplaySettings.DefaultSort, "Сортировка сервисов на главной по умолчанию: order, name или status")
+	compactMode := flag.Bool("compact", false, "Компактный вид главной страницы по умолчанию")
+	var process ProcessOptions
+	flag.StringVar(&process.Service, "service", "", "Служба Windows: install, uninstall, start или stop")
+	flag.StringVar(&process.ServiceName, "service-name", defaultServiceName, "Имя службы Windows")
+	flag.BoolVar(&process.Daemon, "daemon", false, "Запуститься в фоне (Unix)")
+	flag.StringVar(&process.PIDFile, "pidfile", "", "Файл для записи PID процесса")
+	flag.StringVar(&process.LogFile, "log-file", "", "Файл журнала вместо вывода в консоль")
 	flag.Parse()
 	
+	// Команды управления службой Windows не запускают сервер, порт для них не нужен
+	if done, err := runServiceCommand(process); err != nil {
+		log.Fatalf("Ошибка: %v", err)
+	} else if done {
+		return
+	}
+	
 	// Проверяем, что порт указан
 	if *port == "" {
 		fmt.Println("Ошибка: необходимо указать порт через флаг -port")
-		fmt.Println("Пример: go run main.go -port=8080")
+		fmt.Println("Пример: go run . -port=8080")
+		return
+	}
+	
+	// Уход в фон завершает этот процесс
+	if exit, err := setupProcess(process); err != nil {
+		log.Fatalf("Ошибка: %v", err)
+	} else if exit {
 		return
 	}
+	if process.LogFile != "" {
+		if err := redirectOutput(process.LogFile); err != nil {
+			log.Fatalf("Ошибка: %v", err)
+		}
+	}
+	if process.PIDFile != "" {
+		removePIDFile, err := writePIDFile(process.PIDFile)
+		if err != nil {
+			log.Fatalf("Ошибка: %v", err)
+		}
+		defer removePIDFile()
+	}
 	
-	// Инициализируем монитор с файлом для сохранения
+	// Останавливаемся по SIGINT/SIGTERM или команде остановки службы Windows:
+	// контекст прерывает проверки и планировщики. Менеджер служб ждет ответа
+	// не дольше 30 секунд, поэтому служба регистрируется до загрузки
+	// пространств, а о готовности сообщает ready
+	ctx, ready, stop := runContext()
+	defer stop()
+	
+	// Пространство по умолчанию хранит данные рядом с services.json
 	servicesFile := getServicesFilePath()
-	monitor = NewMonitor(servicesFile)
+	dataDir := filepath.Dir(servicesFile)
+	defaultWorkspace := NewWorkspace(defaultWorkspaceID, "По умолчанию", dataDir)
 	
 	// Создаем директорию для данных если нужно
-	if dir := filepath.Dir(servicesFile); dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			log.Printf("Ошибка создания директории %s: %v", dir, err)
+	if dataDir != "." {
+		if err := os.MkdirAll(dataDir, 0755); err != nil {
+			log.Printf("Ошибка создания директории %s: %v", dataDir, err)
+		}
+	}
+	
+	// Настраиваем отправку метрик, если указан адрес
+	var emitter *MetricsEmitter
+	if *metricsAddr != "" {
+		var err error
+		emitter, err = NewMetricsEmitter(*metricsProtocol, *metricsNetwork, *metricsAddr, *metricsPrefix)
+		if err != nil {
+			log.Fatalf("Ошибка настройки метрик: %v", err)
+		}
+		fmt.Printf("Метрики отправляются в %s (%s/%s)\n", *metricsAddr, *metricsProtocol, *metricsNetwork)
+	}
+	
+	// Общий HTTP-транспорт проверок
+	freshConnections, err := parseConnectionMode(*connections)
+	if err != nil {
+		log.Fatalf("Ошибка настройки соединений: %v", err)
+	}
+	client := NewCheckClient(TransportOptions{
+		FreshConnections:      freshConnections,
+		TLSHandshakeTimeout:   *tlsHandshakeTimeout,
+		ResponseHeaderTimeout: *responseHeaderTimeout,
+	})
+	
+	// Приостановка долго недоступных сервисов
+	digestHour, digestMinute, err := parseDigestTime(*digestTime)
+	if err != nil {
+		log.Fatalf("Ошибка настройки приостановки: %v", err)
+	}
+	autoPause := AutoPausePolicy{
+		After:        *autoPauseAfter,
+		Interval:     *pausedInterval,
+		DigestHour:   digestHour,
+		DigestMinute: digestMinute,
+	}
+	
+	// Настройки отображения развертывания: действуют в пространствах,
+	// где их не изменили через API
+	displaySettings := DisplaySettings{
+		RefreshInterval: int(refreshInterval.Round(time.Second) / time.Second),
+		DefaultSort:     *defaultSort,
+		CompactMode:     *compactMode,
+	}
+	if err := validateDisplaySettings(displaySettings); err != nil {
+		log.Fatalf("Ошибка настройки отображения: %v", err)
+	}
+	
+	// Общие настройки проверок для всех пространств
+	historyRetention := time.Duration(*historyDays) * 24 * time.Hour
+	configure := func(ws *Workspace) {
+		ws.monitor.metrics = emitter
+		ws.monitor.history.retention = historyRetention
+		ws.monitor.interval = *interval
+		ws.monitor.timeout = *timeout
+		ws.monitor.client = client
+		ws.monitor.userAgent = *userAgent
+		ws.monitor.autoPause = autoPause
+		ws.settings.SetDefaults(displaySettings)
+	}
+	configure(defaultWorkspace)
+	defaultWorkspace.webhookToken = *webhookToken
+	if *adminUser != "" || *adminPassword != "" {
+		defaultWorkspace.users = []WorkspaceUser{{Username: *adminUser, Password: *adminPassword}}
+		if err := validateWorkspaceUsers(defaultWorkspace.users); err != nil {
+			log.Fatalf("Ошибка настройки администратора: %v", err)
+		}
+	}
+	
+	// Настраиваем уведомления в syslog
+	if *syslogTarget != "" {
+		notifier, err := NewSyslogNotifier(*syslogTarget, *syslogFacility, *syslogSeverityDown, *syslogSeverityUp)
+		if err != nil {
+			log.Fatalf("Ошибка настройки syslog: %v", err)
 		}
+		defaultWorkspace.monitor.notifiers = append(defaultWorkspace.monitor.notifiers, notifier)
+		fmt.Printf("События смены состояния отправляются в syslog (%s)\n", *syslogTarget)
 	}
 	
-	// Загружаем сервисы из файла
-	if err := monitor.LoadFromFile(); err != nil {
-		log.Printf("Ошибка загрузки сервисов: %v", err)
+	// Список сервисов из Git
+	if *gitopsRepo != "" {
+		err := defaultWorkspace.EnableGitOps(GitOpsConfig{
+			Repo:   *gitopsRepo,
+			Branch: *gitopsBranch,
+			Path:   *gitopsPath,
+			SSHKey: *gitopsSSHKey,
+		})
+		if err != nil {
+			log.Fatalf("Ошибка настройки GitOps: %v", err)
+		}
+		fmt.Printf("Сервисы синхронизируются из %s (%s:%s)\n", *gitopsRepo, *gitopsBranch, *gitopsPath)
 	}
 	
+	// Загружаем сервисы и инциденты из файлов
+	defaultWorkspace.Load()
+	
 	// Если файл не существовал или был пуст, добавляем тестовые сервисы
-	if len(monitor.GetServices()) == 0 {
+	if len(defaultWorkspace.monitor.GetServices()) == 0 && defaultWorkspace.gitops == nil {
 		fmt.Println("Добавляем тестовые сервисы...")
-		monitor.AddService("Google", "https://www.google.com")
-		monitor.AddService("GitHub", "https://github.com")
+		defaultWorkspace.monitor.AddService(Service{Name: "Google", URL: "https://www.google.com"})
+		defaultWorkspace.monitor.AddService(Service{Name: "GitHub", URL: "https://github.com"})
+	}
+	
+	router := NewWorkspaceRouter(defaultWorkspace)
+	
+	// Загружаем дополнительные рабочие пространства
+	configs, err := LoadWorkspaceConfigs(filepath.Join(dataDir, "workspaces.json"))
+	if err != nil {
+		log.Fatalf("Ошибка загрузки рабочих пространств: %v", err)
+	}
+	for _, cfg := range configs {
+		dir := filepath.Join(dataDir, "workspaces", cfg.ID)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Fatalf("Ошибка создания директории %s: %v", dir, err)
+		}
+		
+		ws := NewWorkspace(cfg.ID, cfg.Name, dir)
+		configure(ws)
+		ws.monitor.metricsScope = cfg.ID
+		ws.webhookToken = cfg.WebhookToken
+		ws.users = cfg.Users
+		
+		if cfg.Syslog != nil {
+			notifier, err := NewSyslogNotifier(cfg.Syslog.Target, cfg.Syslog.Facility, cfg.Syslog.SeverityDown, cfg.Syslog.SeverityUp)
+			if err != nil {
+				log.Fatalf("Ошибка настройки syslog пространства %s: %v", cfg.ID, err)
+			}
+			ws.monitor.notifiers = append(ws.monitor.notifiers, notifier)
+		}
+		
+		if cfg.GitOps != nil {
+			if err := ws.EnableGitOps(*cfg.GitOps); err != nil {
+				log.Fatalf("Ошибка настройки GitOps пространства %s: %v", cfg.ID, err)
+			}
+		}
+		
+		ws.Load()
+		if _, err := os.Stat(ws.statusPage.filename); os.IsNotExist(err) && len(cfg.Hosts) > 0 {
+			page := ws.statusPage.Get()
+			page.Hosts = cfg.Hosts
+			if err := validateStatusPage(&page); err != nil {
+				log.Fatalf("Ошибка настройки хостов пространства %s: %v", cfg.ID, err)
+			}
+			ws.statusPage.Update(page)
+		}
+		// Хосты могли быть изменены через API раньше и совпасть с хостами из конфигурации
+		if err := router.CheckHosts(ws, ws.statusPage.Get().Hosts); err != nil {
+			log.Fatalf("Ошибка настройки хостов пространства %s: %v", cfg.ID, err)
+		}
+		router.Add(ws)
+		fmt.Printf("Рабочее пространство %s доступно по адресу /w/%s/\n", cfg.ID, cfg.ID)
+	}
+	
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	
+	// Запускаем фоновые проверки во всех пространствах
+	var schedulers sync.WaitGroup
+	for _, ws := range router.workspaces {
+		schedulers.Add(1)
+		go func(m *Monitor) {
+			defer schedulers.Done()
+			m.RunScheduler(ctx, *interval)
+		}(ws.monitor)
+		
+		if ws.gitops != nil {
+			schedulers.Add(1)
+			go func(g *GitOpsSync) {
+				defer schedulers.Done()
+				g.Run(ctx, *gitopsInterval)
+			}(ws.gitops)
+		}
+		
+		if autoPause.After > 0 {
+			schedulers.Add(1)
+			go func(m *Monitor) {
+				defer schedulers.Done()
+				m.RunDigest(ctx)
+			}(ws.monitor)
+		}
+	}
+	fmt.Printf("Сервисы проверяются каждые %s\n", *interval)
+	if autoPause.After > 0 {
+		fmt.Printf("Сервисы, недоступные дольше %s, приостанавливаются; сводка ежедневно в %s\n", autoPause.After, *digestTime)
+	}
+	
+	server := &http.Server{
+		Addr:    ":" + *port,
+		Handler: withCompression(router),
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
 	}
 	
-	// Настраиваем маршруты
-	http.HandleFunc("/", homeHandler)
-	http.HandleFunc("/edit", editHandler)
-	http.HandleFunc("/api/services", servicesHandler)
-	http.HandleFunc("/api/add", addServiceHandler)
-	http.HandleFunc("/api/remove", removeServiceHandler)
+	go func() {
+		<-ctx.Done()
+		fmt.Println("Остановка сервера...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
 	
-	addr := ":" + *port
 	fmt.Printf("Сервер запущен на http://localhost:%s\n", *port)
-	log.Fatal(http.ListenAndServe(addr, nil))
+	ready()
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		// Например, порт занят: останавливаем планировщики и выходим через
+		// отложенные действия, а не log.Fatal
+		log.Printf("Ошибка запуска сервера: %v", err)
+		cancel()
+		exitCode = 1
+	}
+	
+	// Дожидаемся завершения прерванных проверок, чтобы они не писали в файлы после выхода
+	schedulers.Wait()
 }
-func homeHandler(w http.ResponseWriter, r *http.Request) {
+func (ws *Workspace) homeHandler(w http.ResponseWriter, r *http.Request) {
 	tmpl := `
 <!DOCTYPE html>
 <html lang="ru">
@@ -203,7 +913,7 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
             max-width: 800px;
             margin: 0 auto;
             padding: 20px;
-            background-color: #f5f5f5;
+            background-color: var(--background-color, #f5f5f5);
         }
         .container {
             background: white;
@@ -212,9 +922,14 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
             box-shadow: 0 2px 4px rgba(0,0,0,0.1);
         }
         h1 {
-            color: #333;
+            color: var(--primary-color, #333);
             text-align: center;
         }
+        .logo {
+            display: block;
+            max-height: 60px;
+            margin: 0 auto;
+        }
         .service-list {
             margin: 20px 0;
         }
@@ -257,10 +972,17 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
             background-color: #f44336;
             box-shadow: 0 0 6px #f44336;
         }
+        .status-scheduled {
+            background-color: #9e9e9e;
+        }
         .service-name {
             font-weight: bold;
             margin-right: 10px;
         }
+        .service-schedule {
+            color: #666;
+            font-size: 0.9em;
+        }
         .refresh-controls {
             display: flex;
             justify-content: space-between;
@@ -296,22 +1018,79 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
             color: #666;
             font-weight: normal;
         }
+        .sort-select {
+            margin-left: 10px;
+            padding: 2px 4px;
+        }
+        body.compact {
+            padding: 10px;
+        }
+        body.compact .container {
+            padding: 10px 15px;
+        }
+        body.compact h1 {
+            font-size: 1.4em;
+            margin: 5px 0;
+        }
+        body.compact .service-list {
+            margin: 10px 0;
+        }
+        body.compact .service-item {
+            padding: 3px 8px;
+            margin: 2px 0;
+            font-size: 0.9em;
+        }
+        body.compact .status-light {
+            width: 8px;
+            height: 8px;
+        }
+        .incident {
+            padding: 10px 15px;
+            margin: 10px 0;
+            background: #fff8e1;
+            border-radius: 4px;
+            border-left: 4px solid #ff9800;
+        }
+        .incident.resolved {
+            background: #f1f8e9;
+            border-left-color: #4CAF50;
+        }
+        .incident-title {
+            font-weight: bold;
+        }
+        .incident-meta {
+            color: #666;
+            font-size: 0.9em;
+            margin: 5px 0;
+        }
+        .incident-update {
+            font-size: 0.9em;
+            margin: 5px 0 0 10px;
+        }
     </style>
 </head>
 <body>
     <div class="container">
-        <h1>Мониторинг веб-сервисов</h1>
+        <img id="logo" class="logo" alt="" style="display: none">
+        <h1 id="pageTitle">Мониторинг веб-сервисов</h1>
         
         <div class="refresh-controls">
             <div class="countdown">
                 Следующее обновление через: <span id="countdown">10</span> сек
+                <select id="sortOrder" class="sort-select" onchange="renderServices()">
+                    <option value="order">как в списке</option>
+                    <option value="name">по названию</option>
+                    <option value="status">сначала недоступные</option>
+                </select>
             </div>
             <div>
                 <button class="refresh-btn" onclick="manualRefresh()">Обновить сейчас</button>
-                <a href="/edit" target="_blank" class="edit-btn">Редактировать список</a>
+                <a href="edit" target="_blank" class="edit-btn">Редактировать список</a>
             </div>
         </div>
         
+        <div id="incidentList"></div>
+        
         <div class="service-list" id="serviceList">
             <p>Загрузка сервисов...</p>
         </div>
@@ -319,53 +1098,145 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 
     <script>
         let countdownTimer;
-        let refreshTimer;
         let countdownValue = 10;
+        // Настройки отображения пространства, загружаются из api/settings
+        let settings = {refresh_interval: 10, default_sort: 'order', compact_mode: false};
+        let lastSettingsKey = null;
+        let lastServices = null;
+
+        const incidentStatusLabels = {
+            investigating: 'Расследуется',
+            identified: 'Причина установлена',
+            resolved: 'Решен'
+        };
+
+        function escapeHtml(text) {
+            const div = document.createElement('div');
+            div.textContent = text;
+            return div.innerHTML;
+        }
+
+        // Подпись для сервиса вне окна мониторинга, пустая строка - сервис проверяется
+        function scheduleLabel(service) {
+            const now = Date.now();
+            if (service.start_at && new Date(service.start_at).getTime() > now) {
+                return 'мониторинг с ' + new Date(service.start_at).toLocaleString('ru-RU');
+            }
+            if (service.stop_at && new Date(service.stop_at).getTime() <= now) {
+                return 'мониторинг завершен ' + new Date(service.stop_at).toLocaleString('ru-RU');
+            }
+            return '';
+        }
+
+        // Подпись для сервиса на обслуживании, пустая строка - обслуживания нет
+        function maintenanceLabel(service) {
+            const maintenance = service.maintenance;
+            if (!maintenance || (maintenance.until && new Date(maintenance.until).getTime() <= Date.now())) {
+                return '';
+            }
+            return 'обслуживание' + (maintenance.reason ? ': ' + escapeHtml(maintenance.reason) : '') +
+                (maintenance.until ? ' до ' + new Date(maintenance.until).toLocaleString('ru-RU') : '');
+        }
+
+        // Подпись для сервиса, который еще не проверялся после запуска или добавления
+        function uncheckedLabel(service) {
+            return service.last_check ? '' : 'еще не проверялся';
+        }
+
+        function refresh() {
+            loadServices();
+            loadIncidents();
+            loadSettings();
+        }
 
         function updateCountdown() {
-            document.getElementById('countdown').textContent = countdownValue;
+            countdownValue--;
             if (countdownValue <= 0) {
-                countdownValue = 10;
-                loadServices();
-            } else {
-                countdownValue--;
+                countdownValue = settings.refresh_interval;
+                refresh();
             }
+            document.getElementById('countdown').textContent = countdownValue;
         }
 
         function startCountdown() {
-            countdownValue = 10;
+            countdownValue = settings.refresh_interval;
+            document.getElementById('countdown').textContent = countdownValue;
             clearInterval(countdownTimer);
-            clearInterval(refreshTimer);
-            
             countdownTimer = setInterval(updateCountdown, 1000);
-            refreshTimer = setInterval(function() {
-                loadServices();
-            }, 10000);
         }
 
-        function manualRefresh() {
-            loadServices();
-            startCountdown(); // Перезапускаем счетчик
+        // Применяет настройки отображения. Сортировка по умолчанию выставляется
+        // только при первой загрузке, чтобы не сбивать выбор пользователя
+        function applySettings(next) {
+            const first = lastSettingsKey === null;
+            const key = JSON.stringify(next);
+            if (key === lastSettingsKey) {
+                return;
+            }
+            const intervalChanged = next.refresh_interval !== settings.refresh_interval;
+            settings = next;
+            lastSettingsKey = key;
+
+            document.body.classList.toggle('compact', settings.compact_mode);
+            if (first || intervalChanged) {
+                startCountdown();
+            }
+            if (first) {
+                document.getElementById('sortOrder').value = settings.default_sort;
+                renderServices();
+            }
         }
 
-        function loadServices() {
-            fetch('/api/services')
+        function loadSettings() {
+            return fetch('api/settings')
                 .then(response => response.json())
-                .then(services => {
-                    const serviceList = document.getElementById('serviceList');
-                    if (services.length === 0) {
-                        serviceList.innerHTML = '<p>Нет добавленных сервисов</p>';
-                        return;
+                .then(result => applySettings({
+                    refresh_interval: result.refresh_interval,
+                    default_sort: result.default_sort,
+                    compact_mode: result.compact_mode
+                }))
+                .catch(error => {
+                    console.error('Ошибка загрузки настроек:', error);
+                    if (lastSettingsKey === null) {
+                        applySettings(settings);
                     }
-                    
-                    serviceList.innerHTML = services.map((service, index) => 
-                        '<div class="service-item' + (service.status ? '' : ' offline') + '">' +
-                            '<div class="service-info">' +
-                                '<div class="status-light ' + (service.status ? 'status-online' : 'status-offline') + '"></div>' +
-                                '<span class="service-name">' + service.name + '</span>' +
-                            '</div>' +
-                        '</div>'
-                    ).join('');
+                });
+        }
+
+        // Порядок сервисов: недоступные, затем вне окна мониторинга, на обслуживании
+        // и еще не проверенные, затем доступные
+        function statusRank(service) {
+            if (scheduleLabel(service) || maintenanceLabel(service) || uncheckedLabel(service)) {
+                return 1;
+            }
+            return service.status ? 2 : 0;
+        }
+
+        function sortServices(services) {
+            const order = document.getElementById('sortOrder').value;
+            const sorted = services.slice();
+            if (order === 'name') {
+                sorted.sort((a, b) => a.name.localeCompare(b.name, 'ru'));
+            } else if (order === 'status') {
+                sorted.sort((a, b) => statusRank(a) - statusRank(b) || a.name.localeCompare(b.name, 'ru'));
+            }
+            return sorted;
+        }
+
+        function manualRefresh() {
+            // Явно запускаем проверку всех сервисов и показываем свежие результаты
+            fetch('api/services/check', {method: 'POST'})
+                .catch(error => console.error('Ошибка проверки сервисов:', error))
+                .finally(refresh);
+            startCountdown(); // Перезапускаем счетчик
+        }
+
+        function loadServices() {
+            fetch('api/services')
+                .then(response => response.json())
+                .then(services => {
+                    lastServices = services;
+                    renderServices();
                 })
                 .catch(error => {
                     console.error('Ошибка загрузки сервисов:', error);
@@ -373,21 +1244,105 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
                 });
         }
 
-        // Загружаем сервисы при загрузке страницы
-        loadServices();
-        
-        // Запускаем счетчик
-        startCountdown();
+        function renderServices() {
+            if (lastServices === null) {
+                return;
+            }
+            const serviceList = document.getElementById('serviceList');
+            if (lastServices.length === 0) {
+                serviceList.innerHTML = '<p>Нет добавленных сервисов</p>';
+                return;
+            }
+            
+            serviceList.innerHTML = sortServices(lastServices).map(service => {
+                // Сервисы вне окна мониторинга, на обслуживании и еще не проверенные
+                // показываем серыми, а не упавшими
+                const schedule = scheduleLabel(service) || maintenanceLabel(service) || uncheckedLabel(service);
+                if (schedule) {
+                    return '<div class="service-item">' +
+                        '<div class="service-info">' +
+                            '<div class="status-light status-scheduled"></div>' +
+                            '<span class="service-name">' + escapeHtml(service.name) + '</span>' +
+                            '<span class="service-schedule">' + schedule + '</span>' +
+                        '</div>' +
+                    '</div>';
+                }
+                return '<div class="service-item' + (service.status ? '' : ' offline') + '">' +
+                    '<div class="service-info">' +
+                        '<div class="status-light ' + (service.status ? 'status-online' : 'status-offline') + '"></div>' +
+                        '<span class="service-name">' + escapeHtml(service.name) + '</span>' +
+                        (service.paused_at ? '<span class="service-schedule">приостановлен</span>' : '') +
+                    '</div>' +
+                '</div>';
+            }).join('');
+        }
+
+        function loadIncidents() {
+            fetch('api/incidents')
+                .then(response => response.json())
+                .then(incidents => {
+                    // Показываем открытые инциденты и решенные за последнюю неделю
+                    const weekAgo = Date.now() - 7 * 24 * 60 * 60 * 1000;
+                    const visible = incidents.filter(incident =>
+                        incident.status !== 'resolved' || new Date(incident.updated_at).getTime() > weekAgo);
+                    
+                    document.getElementById('incidentList').innerHTML = visible.map(incident =>
+                        '<div class="incident' + (incident.status === 'resolved' ? ' resolved' : '') + '">' +
+                            '<div class="incident-title">' + escapeHtml(incident.title) + '</div>' +
+                            '<div class="incident-meta">' + incidentStatusLabels[incident.status] +
+                                (incident.services.length ? ' · Затронуты: ' + escapeHtml(incident.services.join(', ')) : '') +
+                            '</div>' +
+                            incident.updates.slice().reverse().map(update =>
+                                '<div class="incident-update"><b>' + incidentStatusLabels[update.status] + '</b> ' +
+                                    new Date(update.time).toLocaleString() + ' — ' + escapeHtml(update.message) +
+                                '</div>'
+                            ).join('') +
+                        '</div>'
+                    ).join('');
+                })
+                .catch(error => {
+                    console.error('Ошибка загрузки инцидентов:', error);
+                });
+        }
+
+        function loadStatusPage() {
+            fetch('api/statuspage')
+                .then(response => response.json())
+                .then(page => {
+                    if (page.title) {
+                        document.title = page.title;
+                        document.getElementById('pageTitle').textContent = page.title;
+                    }
+                    if (page.logo_url) {
+                        const logo = document.getElementById('logo');
+                        logo.src = page.logo_url;
+                        logo.style.display = '';
+                    }
+                    if (page.primary_color) {
+                        document.documentElement.style.setProperty('--primary-color', page.primary_color);
+                    }
+                    if (page.background_color) {
+                        document.documentElement.style.setProperty('--background-color', page.background_color);
+                    }
+                })
+                .catch(error => {
+                    console.error('Ошибка загрузки оформления:', error);
+                });
+        }
+
+        // Применяем оформление, загружаем сервисы и инциденты при загрузке страницы.
+        // Счетчик запускается, когда загружены настройки с интервалом обновления
+        loadStatusPage();
+        refresh();
     </script>
 </body>
 </html>
 	`
 	
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	fmt.Fprint(w, tmpl)
+	writeCached(w, r, "text/html; charset=utf-8", []byte(tmpl))
 }
 
-func editHandler(w http.ResponseWriter, r *http.Request) {
+func (ws *Workspace) editHandler(w http.ResponseWriter, r *http.Request) {
 	tmpl := `
 <!DOCTYPE html>
 <html lang="ru">
@@ -436,6 +1391,10 @@ func editHandler(w http.ResponseWriter, r *http.Request) {
             color: #666;
             font-size: 0.9em;
         }
+        .settings-defaults {
+            color: #666;
+            font-size: 0.9em;
+        }
         .delete-btn {
             background: #dc3545;
             color: white;
@@ -450,6 +1409,21 @@ func editHandler(w http.ResponseWriter, r *http.Request) {
         .delete-btn:hover {
             background: #c82333;
         }
+        .check-btn {
+            padding: 5px 10px;
+            font-size: 12px;
+            margin-left: 10px;
+            white-space: nowrap;
+        }
+        .gitops {
+            background: #e8f4fa;
+            border-radius: 4px;
+            padding: 10px 15px;
+            margin-bottom: 20px;
+        }
+        .gitops-error {
+            color: #f44336;
+        }
         .add-form {
             margin-top: 30px;
             padding: 20px;
@@ -464,7 +1438,7 @@ func editHandler(w http.ResponseWriter, r *http.Request) {
             margin-bottom: 5px;
             font-weight: bold;
         }
-        input[type="text"], input[type="url"] {
+        input[type="text"], input[type="url"], input[type="datetime-local"], input[type="number"] {
             width: 100%;
             padding: 8px;
             border: 1px solid #ddd;
@@ -482,12 +1456,45 @@ func editHandler(w http.ResponseWriter, r *http.Request) {
         button:hover {
             background: #005a87;
         }
+        select, textarea {
+            width: 100%;
+            padding: 8px;
+            border: 1px solid #ddd;
+            border-radius: 4px;
+            box-sizing: border-box;
+        }
+        .incident-item {
+            padding: 10px;
+            margin: 5px 0;
+            background: #fff8e1;
+            border-radius: 4px;
+            border-left: 4px solid #ff9800;
+        }
+        .incident-item.resolved {
+            background: #f9f9f9;
+            border-left-color: #4CAF50;
+        }
+        .incident-controls {
+            display: flex;
+            gap: 5px;
+            margin-top: 5px;
+        }
+        .incident-controls select {
+            width: auto;
+        }
+        .checkbox-list label {
+            display: inline-block;
+            font-weight: normal;
+            margin-right: 15px;
+        }
     </style>
 </head>
 <body>
     <div class="container">
         <h1>Редактирование списка сервисов</h1>
         
+        <div id="gitopsStatus"></div>
+        
         <div class="service-list" id="serviceList">
             <p>Загрузка сервисов...</p>
         </div>
@@ -502,30 +1509,227 @@ func editHandler(w http.ResponseWriter, r *http.Request) {
                 <div class="form-group">
                     <label for="serviceUrl">URL сервиса:</label>
                     <input type="url" id="serviceUrl" name="url" required placeholder="https://example.com">
+                    <button type="button" class="check-btn" onclick="discoverHealth(this)" title="Проверить /healthz, /health и /status">Найти health-эндпоинт</button>
+                    <div id="discoveryResult"></div>
+                </div>
+                <div class="form-group">
+                    <label for="serviceGroup">Группа (необязательно):</label>
+                    <input type="text" id="serviceGroup" name="group">
+                </div>
+                <div class="form-group">
+                    <label for="serviceUserAgent">User-Agent (необязательно):</label>
+                    <input type="text" id="serviceUserAgent" name="user_agent" placeholder="По умолчанию — глобальный User-Agent">
+                </div>
+                <div class="form-group">
+                    <label for="serviceCookies">Cookies (необязательно):</label>
+                    <input type="text" id="serviceCookies" name="cookies" placeholder="session=abc; lang=ru">
+                </div>
+                <div class="form-group">
+                    <label for="serviceKeyword">Ключевое слово (необязательно):</label>
+                    <input type="text" id="serviceKeyword" name="keyword" placeholder="Текст, который должен быть в ответе">
+                    <label><input type="checkbox" name="invert_keyword"> Сервис недоступен, если слово найдено</label>
+                </div>
+                <div class="form-group">
+                    <label for="serviceRedirects">Ожидаемая цепочка перенаправлений (необязательно, через пробел, последний адрес — итоговый):</label>
+                    <input type="text" id="serviceRedirects" name="expected_redirects" placeholder="https://example.com/ https://www.example.com/">
+                </div>
+                <div class="form-group">
+                    <label for="serviceStartAt">Начать мониторинг (необязательно):</label>
+                    <input type="datetime-local" id="serviceStartAt" name="start_at">
+                </div>
+                <div class="form-group">
+                    <label for="serviceStopAt">Закончить мониторинг (необязательно):</label>
+                    <input type="datetime-local" id="serviceStopAt" name="stop_at">
                 </div>
                 <button type="submit">Добавить сервис</button>
             </form>
         </div>
+        
+        <div class="add-form">
+            <h3>Импорт из Uptime Kuma / UptimeRobot</h3>
+            <form id="importForm">
+                <div class="form-group">
+                    <label for="importSource">Источник:</label>
+                    <select id="importSource" name="source">
+                        <option value="uptime-kuma">Uptime Kuma (резервная копия JSON)</option>
+                        <option value="uptimerobot">UptimeRobot (ключ API или экспорт JSON)</option>
+                    </select>
+                </div>
+                <div class="form-group">
+                    <label for="importFile">Файл:</label>
+                    <input type="file" id="importFile" name="file" accept=".json,application/json">
+                </div>
+                <div class="form-group">
+                    <label for="importApiKey">Ключ UptimeRobot API (вместо файла):</label>
+                    <input type="text" id="importApiKey" name="api_key">
+                </div>
+                <button type="submit">Импортировать</button>
+            </form>
+            <div id="importResult"></div>
+        </div>
+        
+        <h2>Инциденты</h2>
+        <div id="incidentList">
+            <p>Загрузка инцидентов...</p>
+        </div>
+        
+        <div class="add-form">
+            <h3>Опубликовать инцидент</h3>
+            <form id="addIncidentForm">
+                <div class="form-group">
+                    <label for="incidentTitle">Заголовок:</label>
+                    <input type="text" id="incidentTitle" name="title" required>
+                </div>
+                <div class="form-group">
+                    <label for="incidentStatus">Статус:</label>
+                    <select id="incidentStatus" name="status">
+                        <option value="investigating">Расследуется</option>
+                        <option value="identified">Причина установлена</option>
+                        <option value="resolved">Решен</option>
+                    </select>
+                </div>
+                <div class="form-group">
+                    <label>Затронутые сервисы:</label>
+                    <div class="checkbox-list" id="incidentServices"></div>
+                </div>
+                <div class="form-group">
+                    <label for="incidentMessage">Описание:</label>
+                    <textarea id="incidentMessage" name="message" rows="3" required></textarea>
+                </div>
+                <button type="submit">Опубликовать</button>
+            </form>
+        </div>
+        
+        <div class="add-form">
+            <h3>Оформление страницы статуса</h3>
+            <form id="statusPageForm">
+                <div class="form-group">
+                    <label for="pageTitle">Заголовок:</label>
+                    <input type="text" id="pageTitle" name="title" placeholder="Мониторинг веб-сервисов">
+                </div>
+                <div class="form-group">
+                    <label for="pageLogo">URL логотипа:</label>
+                    <input type="url" id="pageLogo" name="logo_url" placeholder="https://example.com/logo.png">
+                </div>
+                <div class="form-group">
+                    <label for="pagePrimaryColor">Основной цвет:</label>
+                    <input type="text" id="pagePrimaryColor" name="primary_color" placeholder="#333333">
+                </div>
+                <div class="form-group">
+                    <label for="pageBackgroundColor">Цвет фона:</label>
+                    <input type="text" id="pageBackgroundColor" name="background_color" placeholder="#f5f5f5">
+                </div>
+                <div class="form-group">
+                    <label for="pageHosts">Хосты (через запятую):</label>
+                    <input type="text" id="pageHosts" name="hosts" placeholder="status.example.com">
+                </div>
+                <button type="submit">Сохранить</button>
+            </form>
+        </div>
+        
+        <div class="add-form">
+            <h3>Настройки главной страницы</h3>
+            <form id="settingsForm">
+                <div class="form-group">
+                    <label for="settingsRefresh">Интервал обновления, сек:</label>
+                    <input type="number" id="settingsRefresh" name="refresh_interval" min="5" max="3600" required>
+                </div>
+                <div class="form-group">
+                    <label for="settingsSort">Сортировка по умолчанию:</label>
+                    <select id="settingsSort" name="default_sort">
+                        <option value="order">как в списке</option>
+                        <option value="name">по названию</option>
+                        <option value="status">сначала недоступные</option>
+                    </select>
+                </div>
+                <div class="form-group">
+                    <label><input type="checkbox" id="settingsCompact" name="compact_mode"> Компактный вид</label>
+                </div>
+                <p id="settingsDefaults" class="settings-defaults"></p>
+                <button type="submit">Сохранить</button>
+                <button type="button" onclick="resetSettings()">Сбросить</button>
+            </form>
+        </div>
+        
+        <div class="add-form">
+            <h3>Месячный отчет о доступности</h3>
+            <form action="reports/monthly" method="get" target="_blank">
+                <div class="form-group">
+                    <label for="reportMonth">Месяц:</label>
+                    <input type="month" id="reportMonth" name="month">
+                </div>
+                <div class="form-group">
+                    <label for="reportGroup">Группа (пусто — все сервисы):</label>
+                    <input type="text" id="reportGroup" name="group">
+                </div>
+                <button type="submit">Открыть отчет</button>
+                <button type="submit" name="download" value="1">Скачать</button>
+            </form>
+        </div>
     </div>
 
     <script>
+        const incidentStatusLabels = {
+            investigating: 'Расследуется',
+            identified: 'Причина установлена',
+            resolved: 'Решен'
+        };
+
+        function escapeHtml(text) {
+            const div = document.createElement('div');
+            div.textContent = text;
+            return div.innerHTML;
+        }
+
+        // Подпись для сервиса вне окна мониторинга, пустая строка - сервис проверяется
+        function scheduleLabel(service) {
+            const now = Date.now();
+            if (service.start_at && new Date(service.start_at).getTime() > now) {
+                return 'мониторинг с ' + new Date(service.start_at).toLocaleString('ru-RU');
+            }
+            if (service.stop_at && new Date(service.stop_at).getTime() <= now) {
+                return 'мониторинг завершен ' + new Date(service.stop_at).toLocaleString('ru-RU');
+            }
+            return '';
+        }
+
+        // Подпись для сервиса на обслуживании, пустая строка - обслуживания нет
+        function maintenanceLabel(service) {
+            const maintenance = service.maintenance;
+            if (!maintenance || (maintenance.until && new Date(maintenance.until).getTime() <= Date.now())) {
+                return '';
+            }
+            return 'обслуживание' + (maintenance.reason ? ': ' + escapeHtml(maintenance.reason) : '') +
+                (maintenance.until ? ' до ' + new Date(maintenance.until).toLocaleString('ru-RU') : '');
+        }
+
         function loadServices() {
-            fetch('/api/services')
+            fetch('api/services')
                 .then(response => response.json())
                 .then(services => {
+                    document.getElementById('incidentServices').innerHTML = services.map(service =>
+                        '<label><input type="checkbox" name="services" value="' + escapeHtml(service.name) + '"> ' + escapeHtml(service.name) + '</label>'
+                    ).join('');
+                    
                     const serviceList = document.getElementById('serviceList');
                     if (services.length === 0) {
                         serviceList.innerHTML = '<p>Нет добавленных сервисов</p>';
                         return;
                     }
                     
-                    serviceList.innerHTML = services.map((service, index) => 
+                    serviceList.innerHTML = services.map(service => 
                         '<div class="service-item">' +
                             '<div class="service-info">' +
-                                '<div class="service-name">' + service.name + '</div>' +
-                                '<div class="service-url">Адрес: ' + service.url + '</div>' +
+                                '<div class="service-name"><a href="service?id=' + encodeURIComponent(service.id) + '">' + escapeHtml(service.name) + '</a></div>' +
+                                '<div class="service-url">Адрес: ' + escapeHtml(service.url) + (service.group ? ' · Группа: ' + escapeHtml(service.group) : '') +
+                                    (scheduleLabel(service) ? ' · ' + scheduleLabel(service) : '') +
+                                    (maintenanceLabel(service) ? ' · ' + maintenanceLabel(service) : '') +
+                                    (service.managed ? ' · из Git' : '') +
+                                    (service.paused_at ? ' · Приостановлен: недоступен с ' + new Date(service.down_since).toLocaleString('ru-RU') : '') + '</div>' +
                             '</div>' +
-                            '<button class="delete-btn" onclick="removeService(' + index + ')" title="Удалить сервис из списка">Удалить сервис из списка</button>' +
+                            (service.paused_at ? '<button class="check-btn" onclick="resumeService(\'' + service.id + '\')" title="Вернуть обычный интервал проверок">Возобновить</button>' : '') +
+                            '<button class="check-btn" onclick="checkService(\'' + service.id + '\', this)" title="Проверить сервис сейчас">Проверить</button>' +
+                            (service.managed ? '' : '<button class="delete-btn" onclick="removeService(\'' + service.id + '\')" title="Удалить сервис из списка">Удалить сервис из списка</button>') +
                         '</div>'
                     ).join('');
                 })
@@ -535,14 +1739,121 @@ func editHandler(w http.ResponseWriter, r *http.Request) {
                 });
         }
 
-        function removeService(index) {
+        function checkService(id, button) {
+            button.disabled = true;
+            button.textContent = 'Проверка...';
+            fetch('api/services/' + id + '/check', {method: 'POST'})
+                .then(response => response.json())
+                .then(result => {
+                    if (result.success) {
+                        button.textContent = (result.service.status ? 'Доступен' : 'Недоступен') +
+                            ' (' + result.service.latency_ms + ' мс)';
+                    } else {
+                        button.textContent = 'Проверить';
+                        alert('Ошибка проверки сервиса: ' + result.error);
+                    }
+                })
+                .catch(error => {
+                    console.error('Ошибка:', error);
+                    button.textContent = 'Проверить';
+                })
+                .finally(() => {
+                    button.disabled = false;
+                });
+        }
+
+        function loadGitOps() {
+            fetch('api/gitops')
+                .then(response => response.json())
+                .then(result => {
+                    if (!result.enabled) {
+                        return;
+                    }
+                    const status = result.status;
+                    const driftLabels = {
+                        unmanaged: 'нет в репозитории',
+                        conflict: 'конфликт'
+                    };
+                    document.getElementById('gitopsStatus').innerHTML =
+                        '<div class="gitops">' +
+                            '<b>Сервисы синхронизируются из Git:</b> ' + escapeHtml(status.repo) + ' (' + escapeHtml(status.branch) + ':' + escapeHtml(status.path) + ')' +
+                            (status.commit ? '<br>Коммит ' + status.commit.slice(0, 7) + ', синхронизация ' + new Date(status.last_sync).toLocaleString('ru-RU') : '') +
+                            (status.error ? '<br><span class="gitops-error">Ошибка: ' + escapeHtml(status.error) + '</span>' : '') +
+                            (status.drift.length ? '<br>Расхождения с репозиторием:<ul>' + status.drift.map(item =>
+                                '<li>' + escapeHtml(item.name) + ' — ' + driftLabels[item.kind] + ': ' + escapeHtml(item.detail) + '</li>'
+                            ).join('') + '</ul>' : '') +
+                        '</div>';
+                })
+                .catch(error => console.error('Ошибка загрузки состояния GitOps:', error));
+        }
+
+        // Адрес, предложенный последним поиском health-эндпоинта
+        let discoveredUrl = '';
+
+        function discoverHealth(button) {
+            const site = document.getElementById('serviceUrl').value;
+            if (!site) {
+                alert('Введите адрес сайта');
+                return;
+            }
+            
+            button.disabled = true;
+            fetch('api/discover?url=' + encodeURIComponent(site))
+                .then(response => response.json())
+                .then(result => {
+                    if (!result.success) {
+                        alert('Ошибка поиска: ' + result.error);
+                        return;
+                    }
+                    discoveredUrl = result.suggested;
+                    const lines = result.candidates.map(candidate =>
+                        '<li>' + escapeHtml(candidate.path) + ' — ' +
+                            (candidate.error ? 'ошибка' : candidate.status_code + ' (' + escapeHtml(candidate.content_type || 'без типа') + ', ' + candidate.latency_ms + ' мс)') +
+                        '</li>'
+                    ).join('');
+                    document.getElementById('discoveryResult').innerHTML = '<ul>' + lines + '</ul>' +
+                        (result.suggested
+                            ? '<p>Предлагается: ' + escapeHtml(result.suggested) + ' <button type="button" class="check-btn" onclick="useDiscovered()">Использовать</button></p>'
+                            : '<p>Health-эндпоинт не найден, будет проверяться указанный адрес</p>');
+                })
+                .catch(error => {
+                    console.error('Ошибка:', error);
+                    alert('Ошибка поиска health-эндпоинта');
+                })
+                .finally(() => {
+                    button.disabled = false;
+                });
+        }
+
+        function useDiscovered() {
+            document.getElementById('serviceUrl').value = discoveredUrl;
+            document.getElementById('discoveryResult').innerHTML = '';
+        }
+
+        function resumeService(id) {
+            fetch('api/services/' + id + '/resume', {method: 'POST'})
+                .then(response => response.json())
+                .then(result => {
+                    if (result.success) {
+                        loadServices();
+                    } else {
+                        alert('Ошибка возобновления сервиса: ' + result.error);
+                    }
+                })
+                .catch(error => {
+                    console.error('Ошибка:', error);
+                    alert('Ошибка возобновления сервиса');
+                });
+        }
+
+        function removeService(id) {
             if (confirm('Вы уверены, что хотите удалить этот сервис?')) {
-                fetch('/api/remove', {
+                fetch('api/remove', {
                     method: 'POST',
                     headers: {
                         'Content-Type': 'application/json',
                     },
-                    body: JSON.stringify({index: index})
+                    body: JSON.stringify({id: id})
                 })
                 .then(response => response.json())
                 .then(result => {
@@ -559,16 +1870,83 @@ func editHandler(w http.ResponseWriter, r *http.Request) {
             }
         }
 
+        document.getElementById('importForm').addEventListener('submit', function(e) {
+            e.preventDefault();
+            
+            const formData = new FormData(e.target);
+            const file = formData.get('file');
+            const data = {
+                source: formData.get('source'),
+                api_key: formData.get('api_key')
+            };
+            
+            (file && file.size ? file.text() : Promise.resolve(''))
+                .then(text => {
+                    if (text) {
+                        data.data = JSON.parse(text);
+                    }
+                    return fetch('api/import', {
+                        method: 'POST',
+                        headers: {
+                            'Content-Type': 'application/json',
+                        },
+                        body: JSON.stringify(data)
+                    });
+                })
+                .then(response => response.json())
+                .then(result => {
+                    if (!result.success) {
+                        alert('Ошибка импорта: ' + result.error);
+                        return;
+                    }
+                    document.getElementById('importResult').innerHTML =
+                        '<p>Импортировано: ' + result.imported.length + '</p>' +
+                        (result.skipped.length ? '<p>Пропущено:</p><ul>' + result.skipped.map(item =>
+                            '<li>' + escapeHtml(item.name) + ' — ' + escapeHtml(item.reason) + '</li>'
+                        ).join('') + '</ul>' : '') +
+                        (result.notes.length ? '<p>Перенесено с изменениями:</p><ul>' + result.notes.map(item =>
+                            '<li>' + escapeHtml(item.name) + ' — ' + escapeHtml(item.note) + '</li>'
+                        ).join('') + '</ul>' : '');
+                    e.target.reset();
+                    loadServices();
+                })
+                .catch(error => {
+                    console.error('Ошибка:', error);
+                    alert('Ошибка импорта: ' + error.message);
+                });
+        });
+
         document.getElementById('addServiceForm').addEventListener('submit', function(e) {
             e.preventDefault();
             
             const formData = new FormData(e.target);
+            const cookies = {};
+            formData.get('cookies').split(';').forEach(pair => {
+                const separator = pair.indexOf('=');
+                if (separator > 0) {
+                    cookies[pair.slice(0, separator).trim()] = pair.slice(separator + 1).trim();
+                }
+            });
+            
             const data = {
                 name: formData.get('name'),
-                url: formData.get('url')
+                url: formData.get('url'),
+                group: formData.get('group'),
+                user_agent: formData.get('user_agent'),
+                cookies: cookies,
+                keyword: formData.get('keyword'),
+                invert_keyword: formData.get('invert_keyword') === 'on',
+                expected_redirects: formData.get('expected_redirects').split(/\s+/).filter(url => url)
             };
+            // Время из формы - локальное, на сервер отправляем с часовым поясом
+            if (formData.get('start_at')) {
+                data.start_at = new Date(formData.get('start_at')).toISOString();
+            }
+            if (formData.get('stop_at')) {
+                data.stop_at = new Date(formData.get('stop_at')).toISOString();
+            }
             
-            fetch('/api/add', {
+            fetch('api/add', {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json',
@@ -590,35 +1968,253 @@ func editHandler(w http.ResponseWriter, r *http.Request) {
             });
         });
 
-        // Загружаем сервисы при загрузке страницы
+        function loadIncidents() {
+            fetch('api/incidents')
+                .then(response => response.json())
+                .then(incidents => {
+                    const incidentList = document.getElementById('incidentList');
+                    if (incidents.length === 0) {
+                        incidentList.innerHTML = '<p>Нет опубликованных инцидентов</p>';
+                        return;
+                    }
+                    
+                    incidentList.innerHTML = incidents.map(incident =>
+                        '<div class="incident-item' + (incident.status === 'resolved' ? ' resolved' : '') + '">' +
+                            '<div class="service-name">#' + incident.id + ' ' + escapeHtml(incident.title) + '</div>' +
+                            '<div class="service-url">' + incidentStatusLabels[incident.status] +
+                                (incident.services.length ? ' · ' + escapeHtml(incident.services.join(', ')) : '') +
+                                ' · обновлений: ' + incident.updates.length +
+                            '</div>' +
+                            '<div class="incident-controls">' +
+                                '<select id="updateStatus' + incident.id + '">' +
+                                    Object.keys(incidentStatusLabels).map(status =>
+                                        '<option value="' + status + '"' + (status === incident.status ? ' selected' : '') + '>' + incidentStatusLabels[status] + '</option>'
+                                    ).join('') +
+                                '</select>' +
+                                '<input type="text" id="updateMessage' + incident.id + '" placeholder="Текст обновления">' +
+                                '<button onclick="updateIncident(' + incident.id + ')">Добавить</button>' +
+                                '<button class="delete-btn" onclick="removeIncident(' + incident.id + ')">Удалить</button>' +
+                            '</div>' +
+                        '</div>'
+                    ).join('');
+                })
+                .catch(error => {
+                    console.error('Ошибка загрузки инцидентов:', error);
+                    document.getElementById('incidentList').innerHTML = '<p>Ошибка загрузки инцидентов</p>';
+                });
+        }
+
+        function postIncident(url, data, errorText) {
+            return fetch(url, {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify(data)
+            })
+            .then(response => response.json())
+            .then(result => {
+                if (!result.success) {
+                    alert(errorText + ': ' + result.error);
+                    return false;
+                }
+                loadIncidents();
+                return true;
+            })
+            .catch(error => {
+                console.error('Ошибка:', error);
+                alert(errorText);
+                return false;
+            });
+        }
+
+        function updateIncident(id) {
+            postIncident('api/incidents/update', {
+                id: id,
+                status: document.getElementById('updateStatus' + id).value,
+                message: document.getElementById('updateMessage' + id).value
+            }, 'Ошибка обновления инцидента');
+        }
+
+        function removeIncident(id) {
+            if (confirm('Вы уверены, что хотите удалить этот инцидент?')) {
+                postIncident('api/incidents/remove', {id: id}, 'Ошибка удаления инцидента');
+            }
+        }
+
+        document.getElementById('addIncidentForm').addEventListener('submit', function(e) {
+            e.preventDefault();
+            
+            const formData = new FormData(e.target);
+            postIncident('api/incidents/add', {
+                title: formData.get('title'),
+                status: formData.get('status'),
+                services: formData.getAll('services'),
+                message: formData.get('message')
+            }, 'Ошибка публикации инцидента').then(ok => {
+                if (ok) {
+                    e.target.reset();
+                }
+            });
+        });
+
+        function loadStatusPage() {
+            fetch('api/statuspage')
+                .then(response => response.json())
+                .then(page => {
+                    document.getElementById('pageTitle').value = page.title;
+                    document.getElementById('pageLogo').value = page.logo_url;
+                    document.getElementById('pagePrimaryColor').value = page.primary_color;
+                    document.getElementById('pageBackgroundColor').value = page.background_color;
+                    document.getElementById('pageHosts').value = page.hosts.join(', ');
+                })
+                .catch(error => {
+                    console.error('Ошибка загрузки оформления:', error);
+                });
+        }
+
+        document.getElementById('statusPageForm').addEventListener('submit', function(e) {
+            e.preventDefault();
+            
+            const formData = new FormData(e.target);
+            fetch('api/statuspage', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({
+                    title: formData.get('title'),
+                    logo_url: formData.get('logo_url'),
+                    primary_color: formData.get('primary_color'),
+                    background_color: formData.get('background_color'),
+                    hosts: formData.get('hosts').split(',').map(host => host.trim()).filter(host => host)
+                })
+            })
+            .then(response => response.json())
+            .then(result => {
+                if (result.success) {
+                    loadStatusPage();
+                } else {
+                    alert('Ошибка сохранения оформления: ' + result.error);
+                }
+            })
+            .catch(error => {
+                console.error('Ошибка:', error);
+                alert('Ошибка сохранения оформления');
+            });
+        });
+
+        const sortLabels = {order: 'как в списке', name: 'по названию', status: 'сначала недоступные'};
+
+        function loadSettings() {
+            fetch('api/settings')
+                .then(response => response.json())
+                .then(settings => {
+                    document.getElementById('settingsRefresh').value = settings.refresh_interval;
+                    document.getElementById('settingsSort').value = settings.default_sort;
+                    document.getElementById('settingsCompact').checked = settings.compact_mode;
+                    const defaults = settings.defaults;
+                    document.getElementById('settingsDefaults').textContent =
+                        (settings.custom ? 'Настройки пространства изменены. ' : 'Действуют настройки по умолчанию. ') +
+                        'По умолчанию: обновление каждые ' + defaults.refresh_interval + ' сек, сортировка ' +
+                        sortLabels[defaults.default_sort] + (defaults.compact_mode ? ', компактный вид' : '');
+                })
+                .catch(error => {
+                    console.error('Ошибка загрузки настроек:', error);
+                });
+        }
+
+        function saveSettings(body) {
+            fetch('api/settings', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify(body)
+            })
+            .then(response => response.json())
+            .then(result => {
+                if (result.success) {
+                    loadSettings();
+                } else {
+                    alert('Ошибка сохранения настроек: ' + result.error);
+                }
+            })
+            .catch(error => {
+                console.error('Ошибка:', error);
+                alert('Ошибка сохранения настроек');
+            });
+        }
+
+        document.getElementById('settingsForm').addEventListener('submit', function(e) {
+            e.preventDefault();
+            
+            const formData = new FormData(e.target);
+            saveSettings({
+                refresh_interval: parseInt(formData.get('refresh_interval'), 10),
+                default_sort: formData.get('default_sort'),
+                compact_mode: formData.get('compact_mode') !== null
+            });
+        });
+
+        function resetSettings() {
+            if (confirm('Вернуть настройки по умолчанию?')) {
+                saveSettings({reset: true});
+            }
+        }
+
+        // Загружаем сервисы, инциденты, оформление, настройки и состояние GitOps при загрузке страницы
         loadServices();
+        loadIncidents();
+        loadStatusPage();
+        loadSettings();
+        loadGitOps();
     </script>
 </body>
 </html>
 	`
 	
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	fmt.Fprint(w, tmpl)
+	writeCached(w, r, "text/html; charset=utf-8", []byte(tmpl))
+}
+
+func (ws *Workspace) servicesHandler(w http.ResponseWriter, r *http.Request) {
+	// Отдаем результаты последних проверок планировщика. Между проверками
+	// список не меняется, и опрос дашборда получает 304 без тела
+	data, err := json.Marshal(newServiceViews(ws.monitor.GetServices()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeCached(w, r, "application/json", data)
 }
 
-func servicesHandler(w http.ResponseWriter, r *http.Request) {
-	// Проверяем все сервисы в момент запроса
-	monitor.CheckAllServices()
+func (ws *Workspace) checkAllServicesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
 	
-	w.Header().Set("Content-Type", "application/json")
-	services := monitor.GetServices()
-	json.NewEncoder(w).Encode(services)
+	ws.monitor.CheckAllServices(r.Context())
+	writeJSON(w, newServiceViews(ws.monitor.GetServices()))
 }
 
-func addServiceHandler(w http.ResponseWriter, r *http.Request) {
+func (ws *Workspace) addServiceHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
 		return
 	}
 	
 	var req struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
+		Name          string            `json:"name"`
+		URL           string            `json:"url"`
+		Group         string            `json:"group"`
+		UserAgent     string            `json:"user_agent"`
+		Cookies       map[string]string `json:"cookies"`
+		Keyword       string            `json:"keyword"`
+		InvertKeyword bool              `json:"invert_keyword"`
+		Redirects     []string          `json:"expected_redirects"`
+		StartAt       *time.Time        `json:"start_at"`
+		StopAt        *time.Time        `json:"stop_at"`
 	}
 	
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -639,21 +2235,42 @@ func addServiceHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	monitor.AddService(req.Name, req.URL)
+	if req.StartAt != nil && req.StopAt != nil && !req.StopAt.After(*req.StartAt) {
+		writeError(w, "Окончание мониторинга должно быть позже начала")
+		return
+	}
+	
+	service := ws.monitor.AddService(Service{
+		Name:          req.Name,
+		URL:           req.URL,
+		Group:         req.Group,
+		UserAgent:     req.UserAgent,
+		Cookies:       req.Cookies,
+		Keyword:       req.Keyword,
+		InvertKeyword: req.InvertKeyword,
+		Redirects:     req.Redirects,
+		StartAt:       req.StartAt,
+		StopAt:        req.StopAt,
+	})
+	
+	// Проверяем новый сервис сразу, не дожидаясь следующего прохода планировщика
+	if service.Monitored(time.Now()) {
+		go ws.monitor.CheckServiceNow(context.Background(), service.ID)
+	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
 	})
 }
-func removeServiceHandler(w http.ResponseWriter, r *http.Request) {
+func (ws *Workspace) removeServiceHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
 		return
 	}
 	
 	var req struct {
-		Index int `json:"index"`
+		ID string `json:"id"`
 	}
 	
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -665,12 +2282,9 @@ func removeServiceHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	if !monitor.RemoveService(req.Index) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "Неверный индекс сервиса",
-		})
+	// Индексы устаревают при синхронизации GitOps, поэтому сервис удаляется по идентификатору
+	if err := ws.monitor.RemoveService(req.ID); err != nil {
+		writeError(w, err.Error())
 		return
 	}
 	
@@ -678,4 +2292,16 @@ func removeServiceHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
 	})
-}
\ No newline at end of file
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, message string) {
+	writeJSON(w, map[string]interface{}{
+		"success": false,
+		"error":   message,
+	})
+}
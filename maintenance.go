@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Maintenance - режим обслуживания, включенный внешней системой (например,
+// конвейером развертывания). На время обслуживания сервис не проверяется
+type Maintenance struct {
+	Reason string     `json:"reason,omitempty"`
+	Since  time.Time  `json:"since"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// Active сообщает, действует ли обслуживание в момент now
+func (mt *Maintenance) Active(now time.Time) bool {
+	return mt != nil && (mt.Until == nil || now.Before(*mt.Until))
+}
+
+// SetMaintenance включает (mt != nil) или снимает обслуживание у сервисов
+// с указанными идентификаторами. После снятия отсчет недоступности для
+// автоматической приостановки начинается заново, но только у сервисов,
+// которые были на обслуживании: у остальных он продолжается
+func (m *Monitor) SetMaintenance(ids []string, mt *Maintenance) []Service {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	selected := make(map[string]bool)
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	changed := []Service{}
+	for i := range m.services {
+		if !selected[m.services[i].ID] {
+			continue
+		}
+		if mt == nil && m.services[i].Maintenance != nil {
+			m.services[i].DownSince = nil
+		}
+		m.services[i].Maintenance = mt
+		changed = append(changed, m.services[i])
+	}
+	m.saveToFile()
+	return changed
+}
+
+// authorizeWebhook проверяет токен "Authorization: Bearer <token>".
+// Без настроенного токена входящие вебхуки отключены
+func (ws *Workspace) authorizeWebhook(w http.ResponseWriter, r *http.Request) bool {
+	if ws.webhookToken == "" {
+		w.WriteHeader(http.StatusForbidden)
+		writeError(w, "Входящие вебхуки не настроены")
+		return false
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(ws.webhookToken)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeError(w, "Неверный токен")
+		return false
+	}
+	return true
+}
+
+// maintenanceWebhookHandler включает или снимает обслуживание у сервисов,
+// заданных идентификаторами или названиями, и у всех сервисов группы
+func (ws *Workspace) maintenanceWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	if !ws.authorizeWebhook(w, r) {
+		return
+	}
+
+	var req struct {
+		// start или clear
+		Action   string   `json:"action"`
+		Services []string `json:"services"`
+		Group    string   `json:"group"`
+		Reason   string   `json:"reason"`
+		// Длительность обслуживания ("30m"), пусто - до явного снятия
+		Duration string `json:"duration"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, "Неверный формат данных")
+		return
+	}
+
+	now := time.Now()
+	var mt *Maintenance
+	switch req.Action {
+	case "start":
+		mt = &Maintenance{Reason: req.Reason, Since: now}
+		if req.Duration != "" {
+			d, err := time.ParseDuration(req.Duration)
+			if err != nil || d <= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				writeError(w, "Неверная длительность обслуживания (пример: 30m)")
+				return
+			}
+			until := now.Add(d)
+			mt.Until = &until
+		}
+	case "clear":
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, "Неизвестное действие (поддерживаются start и clear)")
+		return
+	}
+
+	if len(req.Services) == 0 && req.Group == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, "Укажите сервисы или группу")
+		return
+	}
+
+	// Сервисы ищем по идентификатору или названию
+	services := ws.monitor.GetServices()
+	var ids []string
+	for _, ref := range req.Services {
+		found := false
+		for _, service := range services {
+			if service.ID == ref || service.Name == ref {
+				ids = append(ids, service.ID)
+				found = true
+			}
+		}
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			writeError(w, fmt.Sprintf("Сервис %q не найден", ref))
+			return
+		}
+	}
+	if req.Group != "" {
+		for _, service := range services {
+			if service.Group == req.Group {
+				ids = append(ids, service.ID)
+			}
+		}
+	}
+
+	changed := ws.monitor.SetMaintenance(ids, mt)
+	for _, service := range changed {
+		if mt != nil {
+			fmt.Printf("Сервис %s переведен в режим обслуживания\n", service.Name)
+		} else {
+			fmt.Printf("Режим обслуживания сервиса %s снят\n", service.Name)
+		}
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"success":  true,
+		"services": newServiceViews(changed),
+	})
+}
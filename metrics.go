@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MetricsEmitter отправляет метрики проверок в Graphite или StatsD.
+// Отправка идет в фоне: недоступный приемник не задерживает проверки
+type MetricsEmitter struct {
+	protocol string
+	network  string
+	addr     string
+	prefix   string
+	sender   *netSender
+}
+
+func NewMetricsEmitter(protocol, network, addr, prefix string) (*MetricsEmitter, error) {
+	if protocol != "graphite" && protocol != "statsd" {
+		return nil, fmt.Errorf("неизвестный протокол метрик %q (поддерживаются graphite и statsd)", protocol)
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("неизвестный тип соединения %q (поддерживаются udp и tcp)", network)
+	}
+
+	e := &MetricsEmitter{
+		protocol: protocol,
+		network:  network,
+		addr:     addr,
+		prefix:   strings.Trim(prefix, "."),
+	}
+	e.sender = newNetSender(addr, func() (net.Conn, error) {
+		return net.DialTimeout(network, addr, 5*time.Second)
+	})
+	return e, nil
+}
+
+// Emit отправляет статус и время ответа одной проверки. Непустой scope
+// добавляется в имя метрики, чтобы разделить рабочие пространства
+func (e *MetricsEmitter) Emit(scope, name string, status bool, latency time.Duration) {
+	base := e.metricName(scope, name)
+	up := 0
+	if status {
+		up = 1
+	}
+	ms := latency.Milliseconds()
+
+	var payload string
+	if e.protocol == "graphite" {
+		ts := time.Now().Unix()
+		payload = fmt.Sprintf("%s.up %d %d\n%s.response_time_ms %d %d\n", base, up, ts, base, ms, ts)
+	} else {
+		payload = fmt.Sprintf("%s.up:%d|g\n%s.response_time_ms:%d|ms\n", base, up, base, ms)
+	}
+
+	e.sender.Send(payload)
+}
+
+func (e *MetricsEmitter) metricName(scope, name string) string {
+	parts := make([]string, 0, 3)
+	if e.prefix != "" {
+		parts = append(parts, e.prefix)
+	}
+	if scope != "" {
+		parts = append(parts, sanitizeMetricPart(scope))
+	}
+	parts = append(parts, sanitizeMetricPart(name))
+	return strings.Join(parts, ".")
+}
+
+func sanitizeMetricPart(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
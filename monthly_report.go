@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+)
+
+var monthNames = []string{
+	"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+}
+
+const (
+	chartWidth  = 600
+	chartHeight = 100
+)
+
+// ChartBar - столбец графика среднего времени ответа за день
+type ChartBar struct {
+	X, Y, Width, Height float64
+	Color               string
+	Title               string
+}
+
+type MonthlyServiceReport struct {
+	Service Service
+	Stats   PeriodStats
+	Bars    []ChartBar
+	MaxMs   float64
+}
+
+type MonthlyIncident struct {
+	Incident Incident
+	Duration string
+	Summary  string
+}
+
+type MonthlyReport struct {
+	Title     string
+	Group     string
+	Month     string
+	Generated time.Time
+	Uptime    float64
+	Services  []MonthlyServiceReport
+	Incidents []MonthlyIncident
+}
+
+var monthlyReportTemplate = template.Must(template.New("monthly").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("02.01.2006 15:04") },
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Title}} — {{.Month}}</title>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            max-width: 800px;
+            margin: 0 auto;
+            padding: 20px;
+            color: #333;
+        }
+        h1, h2 {
+            text-align: center;
+        }
+        .subtitle {
+            text-align: center;
+            color: #666;
+        }
+        table {
+            width: 100%;
+            border-collapse: collapse;
+            margin: 20px 0;
+        }
+        th, td {
+            border-bottom: 1px solid #ddd;
+            padding: 6px 8px;
+            text-align: left;
+        }
+        th {
+            background: #f5f5f5;
+        }
+        .chart {
+            margin: 10px 0 25px;
+            page-break-inside: avoid;
+        }
+        .chart-title {
+            font-weight: bold;
+        }
+        .chart-meta {
+            color: #666;
+            font-size: 0.9em;
+        }
+        @media print {
+            body {
+                max-width: none;
+            }
+        }
+    </style>
+</head>
+<body>
+    <h1>{{.Title}}</h1>
+    <div class="subtitle">
+        Отчет о доступности за {{.Month}}{{if .Group}} · группа «{{.Group}}»{{end}}<br>
+        Средняя доступность: {{printf "%.2f" .Uptime}}% · сформирован {{date .Generated}}
+    </div>
+
+    <h2>Доступность</h2>
+    <table>
+        <tr>
+            <th>Сервис</th>
+            <th>Доступность</th>
+            <th>Проверок</th>
+            <th>Среднее, мс</th>
+            <th>p95, мс</th>
+            <th>Инцидентов</th>
+        </tr>
+        {{range .Services}}
+        <tr>
+            <td>{{.Service.Name}}</td>
+            <td>{{if .Stats.Checks}}{{printf "%.2f" .Stats.Uptime}}%{{else}}—{{end}}</td>
+            <td>{{.Stats.Checks}}</td>
+            <td>{{if .Stats.Checks}}{{.Stats.AvgMs}}{{else}}—{{end}}</td>
+            <td>{{if .Stats.Checks}}{{.Stats.P95Ms}}{{else}}—{{end}}</td>
+            <td>{{.Stats.Incidents}}</td>
+        </tr>
+        {{else}}
+        <tr><td colspan="6">Нет сервисов</td></tr>
+        {{end}}
+    </table>
+
+    <h2>Инциденты</h2>
+    <table>
+        <tr>
+            <th>Инцидент</th>
+            <th>Затронуты</th>
+            <th>Начало</th>
+            <th>Длительность</th>
+            <th>Итог</th>
+        </tr>
+        {{range .Incidents}}
+        <tr>
+            <td>{{.Incident.Title}}</td>
+            <td>{{range $i, $s := .Incident.Services}}{{if $i}}, {{end}}{{$s}}{{end}}</td>
+            <td>{{date .Incident.CreatedAt}}</td>
+            <td>{{.Duration}}</td>
+            <td>{{.Summary}}</td>
+        </tr>
+        {{else}}
+        <tr><td colspan="5">Инцидентов не публиковалось</td></tr>
+        {{end}}
+    </table>
+
+    <h2>Время ответа по дням</h2>
+    {{range .Services}}
+    <div class="chart">
+        <div class="chart-title">{{.Service.Name}}</div>
+        <div class="chart-meta">Максимум: {{.MaxMs}} мс · цвет столбца — доступность за день</div>
+        <svg width="600" height="100" viewBox="0 0 600 100" xmlns="http://www.w3.org/2000/svg">
+            <rect x="0" y="0" width="600" height="100" fill="#f9f9f9"/>
+            {{range .Bars}}
+            <rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="{{.Color}}"><title>{{.Title}}</title></rect>
+            {{end}}
+        </svg>
+    </div>
+    {{end}}
+</body>
+</html>
+`))
+
+// dailyBars строит столбцы среднего времени ответа за каждый день месяца
+func dailyBars(results []CheckResult, from time.Time, days int) ([]ChartBar, float64) {
+	type day struct {
+		stats PeriodStats
+		date  time.Time
+	}
+
+	buckets := make([][]CheckResult, days)
+	for _, result := range results {
+		i := int(result.Time.Sub(from) / (24 * time.Hour))
+		if i >= 0 && i < days {
+			buckets[i] = append(buckets[i], result)
+		}
+	}
+
+	stats := make([]day, days)
+	maxMs := 0.0
+	for i := range buckets {
+		stats[i] = day{stats: ComputePeriodStats(buckets[i]), date: from.AddDate(0, 0, i)}
+		if stats[i].stats.AvgMs > maxMs {
+			maxMs = stats[i].stats.AvgMs
+		}
+	}
+
+	barWidth := float64(chartWidth) / float64(days)
+	bars := make([]ChartBar, 0, days)
+	for i, d := range stats {
+		if d.stats.Checks == 0 {
+			continue
+		}
+
+		color := "#4CAF50"
+		switch {
+		case d.stats.Uptime < 99:
+			color = "#f44336"
+		case d.stats.Uptime < 99.9:
+			color = "#ff9800"
+		}
+
+		// Дни без успешных проверок показываем полной красной полосой
+		height := float64(chartHeight)
+		if maxMs > 0 && d.stats.AvgMs > 0 {
+			height = d.stats.AvgMs / maxMs * (chartHeight - 5)
+		}
+
+		bars = append(bars, ChartBar{
+			X:      roundTo(float64(i)*barWidth+1, 1),
+			Y:      roundTo(chartHeight-height, 1),
+			Width:  roundTo(barWidth-2, 1),
+			Height: roundTo(height, 1),
+			Color:  color,
+			Title:  fmt.Sprintf("%s: %.1f мс, доступность %.2f%%", d.date.Format("02.01"), d.stats.AvgMs, d.stats.Uptime),
+		})
+	}
+	return bars, maxMs
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%d мин", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%d ч %d мин", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// monthlyReportHandler формирует отчет за месяц (?month=2006-01, по умолчанию
+// прошлый месяц) по всем сервисам или по группе (?group=). Отчет рассчитан
+// на печать: PDF получается через «Печать → Сохранить как PDF» в браузере
+func (ws *Workspace) monthlyReportHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, -1, 0)
+	if month := r.URL.Query().Get("month"); month != "" {
+		t, err := time.ParseInLocation("2006-01", month, time.Local)
+		if err != nil {
+			http.Error(w, "Неверный месяц (ожидается формат 2006-01)", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+	to := from.AddDate(0, 1, 0)
+	days := int(to.Sub(from).Hours()/24 + 0.5)
+
+	group := r.URL.Query().Get("group")
+	title := ws.statusPage.Get().Title
+	if title == "" {
+		title = "Мониторинг веб-сервисов"
+	}
+
+	report := MonthlyReport{
+		Title:     title,
+		Group:     group,
+		Month:     fmt.Sprintf("%s %d", monthNames[from.Month()-1], from.Year()),
+		Generated: now,
+	}
+
+	names := make(map[string]bool)
+	uptimeSum := 0.0
+	measured := 0
+	for _, service := range ws.monitor.GetServices() {
+		if group != "" && service.Group != group {
+			continue
+		}
+		names[service.Name] = true
+
+		results := ws.monitor.history.Results(service.ID, from, to)
+		stats := ComputePeriodStats(results)
+		bars, maxMs := dailyBars(results, from, days)
+		report.Services = append(report.Services, MonthlyServiceReport{
+			Service: service,
+			Stats:   stats,
+			Bars:    bars,
+			MaxMs:   maxMs,
+		})
+
+		if stats.Checks > 0 {
+			uptimeSum += stats.Uptime
+			measured++
+		}
+	}
+	if measured > 0 {
+		report.Uptime = roundTo(uptimeSum/float64(measured), 2)
+	}
+
+	for _, incident := range ws.incidents.GetIncidents() {
+		if !incident.CreatedAt.Before(to) || incident.UpdatedAt.Before(from) {
+			continue
+		}
+		if group != "" && !affectsAny(incident, names) {
+			continue
+		}
+
+		end := now
+		if incident.Status == "resolved" {
+			end = incident.UpdatedAt
+		}
+		summary := ""
+		if len(incident.Updates) > 0 {
+			summary = incident.Updates[len(incident.Updates)-1].Message
+		}
+		report.Incidents = append(report.Incidents, MonthlyIncident{
+			Incident: incident,
+			Duration: formatDuration(end.Sub(incident.CreatedAt)),
+			Summary:  summary,
+		})
+	}
+
+	if r.URL.Query().Get("download") != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"report-%s.html\"", from.Format("2006-01")))
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := monthlyReportTemplate.Execute(w, report); err != nil {
+		log.Printf("Ошибка формирования отчета: %v", err)
+	}
+}
+
+func affectsAny(incident Incident, names map[string]bool) bool {
+	for _, name := range incident.Services {
+		if names[name] {
+			return true
+		}
+	}
+	return false
+}
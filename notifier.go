@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// StateChange описывает смену состояния сервиса после проверки
+type StateChange struct {
+	Service  Service
+	Previous bool
+	Current  bool
+	Time     time.Time
+	// Причина падения, пусто при восстановлении
+	Failure *CheckFailure
+}
+
+// Notifier доставляет события смены состояния во внешние системы
+type Notifier interface {
+	Notify(event StateChange) error
+}
+
+func (e StateChange) Message() string {
+	if e.Current {
+		return fmt.Sprintf("Сервис %s (%s) снова доступен", e.Service.Name, e.Service.URL)
+	}
+	if e.Failure != nil {
+		return fmt.Sprintf("Сервис %s (%s) недоступен: %s", e.Service.Name, e.Service.URL, e.Failure)
+	}
+	return fmt.Sprintf("Сервис %s (%s) недоступен", e.Service.Name, e.Service.URL)
+}
+
+func (m *Monitor) notify(events []StateChange) {
+	for _, event := range events {
+		for _, n := range m.notifiers {
+			if err := n.Notify(event); err != nil {
+				fmt.Printf("Ошибка отправки уведомления о сервисе %s: %v\n", event.Service.Name, err)
+			}
+		}
+	}
+}
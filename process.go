@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ProcessOptions - как запущен процесс. Service - действие со службой Windows
+// (install, uninstall, start или stop), Daemon - уход в фон в Unix
+type ProcessOptions struct {
+	Service     string
+	ServiceName string
+	Daemon      bool
+	PIDFile     string
+	LogFile     string
+}
+
+const defaultServiceName = "web-monitor"
+
+// redirectOutput направляет вывод программы и журнал в файл: у службы
+// и демона нет терминала
+func redirectOutput(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия журнала %s: %v", path, err)
+	}
+	os.Stdout = file
+	os.Stderr = file
+	log.SetOutput(file)
+	return nil
+}
+
+// writePIDFile записывает pid процесса и возвращает функцию удаления файла.
+// Если файл указывает на работающий процесс, второй экземпляр не запускается
+func writePIDFile(path string) (func(), error) {
+	if data, err := ioutil.ReadFile(path); err == nil {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && pid != os.Getpid() && processAlive(pid) {
+			return nil, fmt.Errorf("процесс уже запущен (PID %d из %s)", pid, path)
+		}
+	}
+
+	if err := ioutil.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return nil, fmt.Errorf("ошибка записи pid-файла %s: %v", path, err)
+	}
+	return func() { os.Remove(path) }, nil
+}
+
+// withoutFlag убирает флаг name со значением из аргументов командной строки
+// (формы -name=value, --name=value и -name value)
+func withoutFlag(args []string, name string) []string {
+	var result []string
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimLeft(args[i], "-")
+		if arg == name && strings.HasPrefix(args[i], "-") {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, name+"=") && strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		result = append(result, args[i])
+	}
+	return result
+}
@@ -0,0 +1,57 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// Переменная окружения, по которой процесс понимает, что он уже демон
+const daemonEnv = "WEB_MONITOR_DAEMON"
+
+// runServiceCommand выполняет действие -service. Службы есть только в Windows
+func runServiceCommand(opts ProcessOptions) (bool, error) {
+	if opts.Service != "" {
+		return false, fmt.Errorf("флаг -service поддерживается только в Windows, используйте -daemon")
+	}
+	return false, nil
+}
+
+// setupProcess готовит процесс к запуску сервера. Возвращает true, если
+// этот процесс должен завершиться: с -daemon сервер работает в дочернем
+func setupProcess(opts ProcessOptions) (bool, error) {
+	if !opts.Daemon || os.Getenv(daemonEnv) != "" {
+		return false, nil
+	}
+
+	// Go не умеет fork, поэтому перезапускаем себя в новой сессии без терминала.
+	// Вывод дочернего процесса идет в -log-file или в /dev/null
+	exe, err := os.Executable()
+	if err != nil {
+		return false, fmt.Errorf("ошибка определения исполняемого файла: %v", err)
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), daemonEnv+"=1")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return false, fmt.Errorf("ошибка запуска в фоне: %v", err)
+	}
+	fmt.Printf("Монитор запущен в фоне, PID %d\n", cmd.Process.Pid)
+	return true, nil
+}
+
+// runContext возвращает контекст работы сервера, отменяемый по SIGINT/SIGTERM,
+// функцию сообщения о готовности (в Unix ничего не делает) и функцию остановки
+func runContext() (context.Context, func(), func()) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	return ctx, func() {}, stop
+}
+
+func processAlive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
@@ -0,0 +1,297 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Имя службы, под которым процесс запущен менеджером служб
+var runningService string
+
+// runServiceCommand выполняет действие -service. Возвращает true, если
+// действие выполнено и сервер запускать не нужно
+func runServiceCommand(opts ProcessOptions) (bool, error) {
+	if opts.ServiceName == "" {
+		opts.ServiceName = defaultServiceName
+	}
+
+	switch opts.Service {
+	case "":
+		return false, nil
+	case "install":
+		return true, installService(opts.ServiceName)
+	case "uninstall":
+		return true, uninstallService(opts.ServiceName)
+	case "start":
+		return true, controlService(opts.ServiceName, "start")
+	case "stop":
+		return true, controlService(opts.ServiceName, "stop")
+	default:
+		return false, fmt.Errorf("неизвестное действие -service %q (install, uninstall, start, stop)", opts.Service)
+	}
+}
+
+// setupProcess готовит процесс к работе службой, если его запустил менеджер служб
+func setupProcess(opts ProcessOptions) (bool, error) {
+	if opts.Daemon {
+		return false, fmt.Errorf("флаг -daemon не поддерживается в Windows, используйте -service install")
+	}
+	if opts.ServiceName == "" {
+		opts.ServiceName = defaultServiceName
+	}
+
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return false, fmt.Errorf("ошибка определения режима запуска: %v", err)
+	}
+	if !isService {
+		return false, nil
+	}
+
+	// Служба стартует в system32: относительные пути (services.json,
+	// workspaces) считаются от каталога программы
+	runningService = opts.ServiceName
+	exe, err := os.Executable()
+	if err != nil {
+		return false, fmt.Errorf("ошибка определения исполняемого файла: %v", err)
+	}
+	if err := os.Chdir(filepath.Dir(exe)); err != nil {
+		return false, fmt.Errorf("ошибка смены каталога: %v", err)
+	}
+	if opts.LogFile == "" {
+		if err := redirectOutput(filepath.Join(filepath.Dir(exe), "web-monitor.log")); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// installService регистрирует службу с автозапуском. Служба запускается с
+// теми же флагами, что и команда установки, кроме самого -service
+func installService(name string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("ошибка определения исполняемого файла: %v", err)
+	}
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к менеджеру служб: %v", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(name); err == nil {
+		s.Close()
+		return fmt.Errorf("служба %s уже установлена", name)
+	}
+
+	// Служба запускается с флагами установки, без порта она не стартует
+	if f := flag.Lookup("port"); f == nil || f.Value.String() == "" {
+		return fmt.Errorf("укажите -port: служба запускается с флагами команды установки")
+	}
+	args := withoutFlag(os.Args[1:], "service")
+	s, err := m.CreateService(name, exe, mgr.Config{
+		DisplayName: "Web Monitor",
+		Description: "Мониторинг доступности веб-сервисов",
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("ошибка установки службы: %v", err)
+	}
+	defer s.Close()
+
+	fmt.Printf("Служба %s установлена\n", name)
+	return nil
+}
+
+func uninstallService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к менеджеру служб: %v", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("служба %s не установлена", name)
+	}
+	defer s.Close()
+
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("ошибка удаления службы: %v", err)
+	}
+	fmt.Printf("Служба %s удалена\n", name)
+	return nil
+}
+
+// controlService запускает или останавливает службу и ждет смены состояния
+func controlService(name, action string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к менеджеру служб: %v", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("служба %s не установлена", name)
+	}
+	defer s.Close()
+
+	want := svc.Running
+	if action == "start" {
+		err = s.Start()
+	} else {
+		want = svc.Stopped
+		_, err = s.Control(svc.Stop)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка управления службой: %v", err)
+	}
+
+	// Пока служба загружает данные, она увеличивает CheckPoint: отсчет
+	// 30 секунд начинается заново с каждым подтверждением
+	deadline := time.Now().Add(30 * time.Second)
+	var checkPoint uint32
+	for {
+		status, err := s.Query()
+		if err != nil {
+			return fmt.Errorf("ошибка запроса состояния службы: %v", err)
+		}
+		if status.State == want {
+			break
+		}
+		if status.CheckPoint != checkPoint {
+			checkPoint = status.CheckPoint
+			deadline = time.Now().Add(30 * time.Second)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("служба %s не перешла в нужное состояние за 30 секунд", name)
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+	fmt.Printf("Служба %s: %s\n", name, map[string]string{"start": "запущена", "stop": "остановлена"}[action])
+	return nil
+}
+
+// Как часто служба подтверждает менеджеру, что еще запускается
+const startPendingInterval = 10 * time.Second
+
+// serviceHandler принимает команды менеджера служб. Пока main загружает
+// пространства, служба в состоянии StartPending; Running сообщается после
+// закрытия ready. Stop и Shutdown отменяют контекст сервера; состояние
+// Stopped сообщается только после того, как main завершит работу и закроет done
+type serviceHandler struct {
+	cancel context.CancelFunc
+	ready  chan struct{}
+	done   chan struct{}
+}
+
+func (h *serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	const accepted = svc.AcceptStop | svc.AcceptShutdown
+
+	// Загрузка истории может занять больше 30 секунд: увеличиваем CheckPoint,
+	// чтобы менеджер служб не счел запуск зависшим
+	pending := svc.Status{State: svc.StartPending, WaitHint: uint32(2 * startPendingInterval / time.Millisecond)}
+	status <- pending
+	ticker := time.NewTicker(startPendingInterval)
+	defer ticker.Stop()
+	for starting := true; starting; {
+		select {
+		case <-h.ready:
+			starting = false
+		case <-ticker.C:
+			pending.CheckPoint++
+			status <- pending
+		case request := <-requests:
+			if request.Cmd == svc.Interrogate {
+				status <- pending
+			}
+		case <-h.done:
+			status <- svc.Status{State: svc.StopPending}
+			return false, 0
+		}
+	}
+	status <- svc.Status{State: svc.Running, Accepts: accepted}
+
+	for {
+		select {
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				status <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				h.cancel()
+				<-h.done
+				return false, 0
+			}
+		case <-h.done:
+			status <- svc.Status{State: svc.StopPending}
+			return false, 0
+		}
+	}
+}
+
+// runContext возвращает контекст работы сервера. Под менеджером служб он
+// отменяется командой остановки службы, в консоли - по Ctrl+C.
+// Вторая функция сообщает менеджеру, что служба запущена. Третья
+// вызывается в конце main: для службы она дожидается, пока менеджеру
+// будет сообщено об остановке
+func runContext() (context.Context, func(), func()) {
+	if runningService == "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		return ctx, func() {}, stop
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := &serviceHandler{cancel: cancel, ready: make(chan struct{}), done: make(chan struct{})}
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		if err := svc.Run(runningService, handler); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка работы службы: %v\n", err)
+			cancel()
+		}
+	}()
+
+	var readyOnce, once sync.Once
+	ready := func() {
+		readyOnce.Do(func() { close(handler.ready) })
+	}
+	return ctx, ready, func() {
+		once.Do(func() {
+			cancel()
+			close(handler.done)
+			<-finished
+		})
+	}
+}
+
+// STILL_ACTIVE - код завершения еще работающего процесса
+const stillActive = 259
+
+func processAlive(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(handle)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Сколько перенаправлений проходит проверка, как и стандартный клиент Go
+const maxRedirects = 10
+
+type redirectRecorderKey struct{}
+
+// redirectRecorder накапливает адреса, по которым клиент прошел перенаправления
+type redirectRecorder struct {
+	chain []string
+}
+
+// withRedirectRecorder включает запись цепочки перенаправлений запроса
+func withRedirectRecorder(ctx context.Context) (context.Context, *redirectRecorder) {
+	recorder := &redirectRecorder{}
+	return context.WithValue(ctx, redirectRecorderKey{}, recorder), recorder
+}
+
+// checkRedirect - политика перенаправлений клиента проверок: ограничивает
+// их число, обнаруживает циклы и записывает цепочку, если это запрошено
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("больше %d перенаправлений", maxRedirects)
+	}
+	for _, prev := range via {
+		if prev.URL.String() == req.URL.String() {
+			return fmt.Errorf("цикл перенаправлений на %s", req.URL)
+		}
+	}
+	if recorder, ok := req.Context().Value(redirectRecorderKey{}).(*redirectRecorder); ok {
+		recorder.chain = append(recorder.chain, req.URL.String())
+	}
+	return nil
+}
+
+// normalizeRedirectURL приводит адрес из ожидаемой цепочки к виду,
+// в котором его записывает клиент
+func normalizeRedirectURL(s string) string {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return s
+	}
+	return u.String()
+}
+
+// checkRedirectChain сравнивает пройденную цепочку перенаправлений с
+// ожидаемой. Последний адрес ожидаемой цепочки - итоговый URL
+func checkRedirectChain(resp *http.Response, chain, expected []string) *CheckFailure {
+	match := len(chain) == len(expected)
+	for i := 0; match && i < len(chain); i++ {
+		match = chain[i] == normalizeRedirectURL(expected[i])
+	}
+	if match {
+		return nil
+	}
+
+	failure := &CheckFailure{StatusLine: resp.Proto + " " + resp.Status}
+	if len(chain) == 0 {
+		failure.Snippet = fmt.Sprintf("перенаправлений не было, ожидалась цепочка %s", strings.Join(expected, " → "))
+	} else {
+		failure.Snippet = fmt.Sprintf("цепочка перенаправлений %s, ожидалась %s", strings.Join(chain, " → "), strings.Join(expected, " → "))
+	}
+	return failure
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ReportPeriod - границы периода отчета [From, To)
+type ReportPeriod struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// PeriodStats - показатели сервиса за период отчета
+type PeriodStats struct {
+	LatencyStats
+	Incidents int `json:"incidents"`
+}
+
+// PeriodDelta - разница показателей текущего и предыдущего периода.
+// Поля пустые, если в одном из периодов не было проверок
+type PeriodDelta struct {
+	Uptime    *float64 `json:"uptime"`
+	AvgMs     *float64 `json:"avg_ms"`
+	P95Ms     *float64 `json:"p95_ms"`
+	Incidents *int     `json:"incidents"`
+}
+
+type ServiceComparison struct {
+	ID       string      `json:"id"`
+	Name     string      `json:"name"`
+	Current  PeriodStats `json:"current"`
+	Previous PeriodStats `json:"previous"`
+	Delta    PeriodDelta `json:"delta"`
+}
+
+// ComputePeriodStats считает показатели за период. Инцидентом считается
+// каждый переход сервиса в недоступное состояние
+func ComputePeriodStats(results []CheckResult) PeriodStats {
+	stats := PeriodStats{LatencyStats: ComputeLatencyStats(results)}
+
+	up := true
+	for _, result := range results {
+		if up && !result.Status {
+			stats.Incidents++
+		}
+		up = result.Status
+	}
+	return stats
+}
+
+func comparePeriods(current, previous PeriodStats) PeriodDelta {
+	if current.Checks == 0 || previous.Checks == 0 {
+		return PeriodDelta{}
+	}
+
+	uptime := roundTo(current.Uptime-previous.Uptime, 2)
+	avg := roundTo(current.AvgMs-previous.AvgMs, 1)
+	p95 := roundTo(current.P95Ms-previous.P95Ms, 1)
+	incidents := current.Incidents - previous.Incidents
+	return PeriodDelta{
+		Uptime:    &uptime,
+		AvgMs:     &avg,
+		P95Ms:     &p95,
+		Incidents: &incidents,
+	}
+}
+
+// parseReportTime принимает дату (2006-01-02) или время в формате RFC 3339
+func parseReportTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("неверная дата %q", s)
+	}
+	return t, nil
+}
+
+// comparisonPeriods определяет сравниваемые периоды по параметрам запроса:
+// либо period=day|week|month (последний период против предыдущего такой же длины),
+// либо явные границы from, to, compare_from, compare_to
+func comparisonPeriods(r *http.Request) (ReportPeriod, ReportPeriod, error) {
+	q := r.URL.Query()
+
+	if q.Get("from") != "" {
+		var bounds [4]time.Time
+		for i, name := range []string{"from", "to", "compare_from", "compare_to"} {
+			t, err := parseReportTime(q.Get(name))
+			if err != nil {
+				return ReportPeriod{}, ReportPeriod{}, fmt.Errorf("Параметр %s: %v", name, err)
+			}
+			bounds[i] = t
+		}
+		current := ReportPeriod{From: bounds[0], To: bounds[1]}
+		previous := ReportPeriod{From: bounds[2], To: bounds[3]}
+		if !current.From.Before(current.To) || !previous.From.Before(previous.To) {
+			return ReportPeriod{}, ReportPeriod{}, fmt.Errorf("Начало периода должно быть раньше конца")
+		}
+		return current, previous, nil
+	}
+
+	lengths := map[string]time.Duration{
+		"day":   24 * time.Hour,
+		"week":  7 * 24 * time.Hour,
+		"month": 30 * 24 * time.Hour,
+	}
+	period := q.Get("period")
+	if period == "" {
+		period = "week"
+	}
+	length, ok := lengths[period]
+	if !ok {
+		return ReportPeriod{}, ReportPeriod{}, fmt.Errorf("Неверный период (day, week или month)")
+	}
+
+	now := time.Now()
+	current := ReportPeriod{From: now.Add(-length), To: now}
+	previous := ReportPeriod{From: now.Add(-2 * length), To: now.Add(-length)}
+	return current, previous, nil
+}
+
+func (ws *Workspace) compareReportHandler(w http.ResponseWriter, r *http.Request) {
+	current, previous, err := comparisonPeriods(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, err.Error())
+		return
+	}
+
+	services := ws.monitor.GetServices()
+	comparisons := make([]ServiceComparison, 0, len(services))
+	for _, service := range services {
+		cur := ComputePeriodStats(ws.monitor.history.Results(service.ID, current.From, current.To))
+		prev := ComputePeriodStats(ws.monitor.history.Results(service.ID, previous.From, previous.To))
+		comparisons = append(comparisons, ServiceComparison{
+			ID:       service.ID,
+			Name:     service.Name,
+			Current:  cur,
+			Previous: prev,
+			Delta:    comparePeriods(cur, prev),
+		})
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"current":  current,
+		"previous": previous,
+		"services": comparisons,
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// Сообщения, ожидающие отправки; при переполнении новые отбрасываются
+const senderQueueSize = 1024
+
+// Пауза между попытками подключения к недоступному приемнику растет от
+// минимальной до максимальной, сообщения за это время отбрасываются
+const (
+	minSenderBackoff = time.Second
+	maxSenderBackoff = time.Minute
+)
+
+// netSender доставляет сообщения в Graphite, StatsD или syslog из фоновой
+// горутины. Проверки только ставят сообщение в очередь и не ждут
+// подключения к недоступному приемнику
+type netSender struct {
+	name    string
+	dial    func() (net.Conn, error)
+	queue   chan string
+	dropped atomic.Int64
+}
+
+// newNetSender создает отправителя и запускает его фоновую горутину.
+// name - адрес приемника для сообщений журнала
+func newNetSender(name string, dial func() (net.Conn, error)) *netSender {
+	s := &netSender{
+		name:  name,
+		dial:  dial,
+		queue: make(chan string, senderQueueSize),
+	}
+	go s.run()
+	return s
+}
+
+// Send ставит сообщение в очередь без ожидания. Возвращает false, если
+// очередь переполнена и сообщение отброшено
+func (s *netSender) Send(msg string) bool {
+	select {
+	case s.queue <- msg:
+		return true
+	default:
+		s.dropped.Add(1)
+		return false
+	}
+}
+
+func (s *netSender) run() {
+	var conn net.Conn
+	var retryAt time.Time
+	backoff := minSenderBackoff
+
+	// fail откладывает следующую попытку подключения
+	fail := func(format string, err error) {
+		log.Printf(format, s.name, err, backoff)
+		retryAt = time.Now().Add(backoff)
+		backoff = min(backoff*2, maxSenderBackoff)
+		s.dropped.Add(1)
+	}
+
+	for msg := range s.queue {
+		if conn == nil {
+			if time.Now().Before(retryAt) {
+				s.dropped.Add(1)
+				continue
+			}
+			var err error
+			if conn, err = s.dial(); err != nil {
+				fail("Ошибка подключения к %s: %v, повтор через %v", err)
+				continue
+			}
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			conn.Close()
+			conn = nil
+			fail("Ошибка отправки в %s: %v, повтор через %v", err)
+			continue
+		}
+		backoff = minSenderBackoff
+		if dropped := s.dropped.Swap(0); dropped > 0 {
+			log.Printf("Отправка в %s восстановлена, пропущено сообщений: %d", s.name, dropped)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Допустимый интервал автообновления дашборда, секунды
+const (
+	minRefreshInterval = 5
+	maxRefreshInterval = 3600
+)
+
+// Порядок сервисов на главной: как в списке, по названию или сначала недоступные
+var sortOrders = map[string]bool{"order": true, "name": true, "status": true}
+
+// DisplaySettings - настройки отображения главной страницы пространства
+type DisplaySettings struct {
+	RefreshInterval int    `json:"refresh_interval"`
+	DefaultSort     string `json:"default_sort"`
+	CompactMode     bool   `json:"compact_mode"`
+}
+
+// Настройки по умолчанию, если администратор не задал другие флагами
+var defaultDisplaySettings = DisplaySettings{
+	RefreshInterval: 10,
+	DefaultSort:     "order",
+}
+
+func validateDisplaySettings(settings DisplaySettings) error {
+	if settings.RefreshInterval < minRefreshInterval || settings.RefreshInterval > maxRefreshInterval {
+		return fmt.Errorf("Интервал обновления должен быть от %d до %d секунд", minRefreshInterval, maxRefreshInterval)
+	}
+	if !sortOrders[settings.DefaultSort] {
+		return fmt.Errorf("Неизвестная сортировка %q (order, name, status)", settings.DefaultSort)
+	}
+	return nil
+}
+
+// SettingsStore хранит настройки отображения пространства. Пока они не
+// изменены через API, действуют настройки развертывания, заданные флагами
+type SettingsStore struct {
+	defaults DisplaySettings
+	custom   *DisplaySettings
+	mutex    sync.RWMutex
+	filename string
+}
+
+func NewSettingsStore(filename string) *SettingsStore {
+	return &SettingsStore{
+		defaults: defaultDisplaySettings,
+		filename: filename,
+	}
+}
+
+func (s *SettingsStore) LoadFromFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла %s: %v", s.filename, err)
+	}
+
+	var settings DisplaySettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("ошибка парсинга JSON из файла %s: %v", s.filename, err)
+	}
+	if err := validateDisplaySettings(settings); err != nil {
+		return fmt.Errorf("неверные настройки в файле %s: %v", s.filename, err)
+	}
+	s.custom = &settings
+	return nil
+}
+
+func (s *SettingsStore) saveToFile() error {
+	data, err := json.MarshalIndent(s.custom, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации в JSON: %v", err)
+	}
+
+	if err := ioutil.WriteFile(s.filename, data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи в файл %s: %v", s.filename, err)
+	}
+	return nil
+}
+
+// SetDefaults задает настройки развертывания
+func (s *SettingsStore) SetDefaults(settings DisplaySettings) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.defaults = settings
+}
+
+// Get возвращает действующие настройки, настройки развертывания и признак
+// того, что настройки пространства изменены
+func (s *SettingsStore) Get() (DisplaySettings, DisplaySettings, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.custom != nil {
+		return *s.custom, s.defaults, true
+	}
+	return s.defaults, s.defaults, false
+}
+
+func (s *SettingsStore) Update(settings DisplaySettings) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.custom = &settings
+	return s.saveToFile()
+}
+
+// Reset возвращает пространство к настройкам развертывания
+func (s *SettingsStore) Reset() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.custom = nil
+	if err := os.Remove(s.filename); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("ошибка удаления файла %s: %v", s.filename, err)
+	}
+	return nil
+}
+
+// settingsResponse - действующие настройки вместе с настройками развертывания
+type settingsResponse struct {
+	DisplaySettings
+	Custom   bool            `json:"custom"`
+	Defaults DisplaySettings `json:"defaults"`
+}
+
+func (ws *Workspace) settingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		settings, defaults, custom := ws.settings.Get()
+		writeJSON(w, settingsResponse{DisplaySettings: settings, Custom: custom, Defaults: defaults})
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		DisplaySettings
+		Reset bool `json:"reset"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, "Неверный формат данных")
+		return
+	}
+
+	if request.Reset {
+		if err := ws.settings.Reset(); err != nil {
+			writeError(w, err.Error())
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"success": true,
+		})
+		return
+	}
+
+	if err := validateDisplaySettings(request.DisplaySettings); err != nil {
+		writeError(w, err.Error())
+		return
+	}
+	if err := ws.settings.Update(request.DisplaySettings); err != nil {
+		writeError(w, err.Error())
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"success": true,
+	})
+}
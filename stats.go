@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseWindow разбирает длительность окна статистики: "1h", "24h", "7d", "30d"
+func parseWindow(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("неверное окно %q", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("неверное окно %q", s)
+	}
+	return d, nil
+}
+
+// serviceItemHandler обслуживает запросы вида /api/services/{id}/{действие}
+func (ws *Workspace) serviceItemHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/services/"), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	service, ok := ws.monitor.GetService(parts[0])
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeError(w, "Сервис не найден")
+		return
+	}
+
+	switch parts[1] {
+	case "stats":
+		ws.serviceStatsHandler(w, r, service)
+	case "check":
+		ws.checkServiceHandler(w, r, service)
+	case "resume":
+		ws.resumeServiceHandler(w, r, service)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (ws *Workspace) serviceStatsHandler(w http.ResponseWriter, r *http.Request, service Service) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "24h"
+	}
+
+	d, err := parseWindow(window)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, "Неверное окно статистики (примеры: 1h, 24h, 7d, 30d)")
+		return
+	}
+
+	now := time.Now()
+	results := ws.monitor.history.Results(service.ID, now.Add(-d), now)
+
+	writeJSON(w, map[string]interface{}{
+		"service": newServiceView(service),
+		"window":  window,
+		"stats":   ComputeLatencyStats(results),
+		"timings": ComputeTimingStats(results),
+	})
+}
+
+func (ws *Workspace) checkServiceHandler(w http.ResponseWriter, r *http.Request, service Service) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Сервис могли удалить между поиском и проверкой
+	checked, ok := ws.monitor.CheckServiceNow(r.Context(), service.ID)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeError(w, "Сервис не найден")
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"success": true,
+		"service": newServiceView(checked),
+	})
+}
+
+func (ws *Workspace) serviceDetailHandler(w http.ResponseWriter, r *http.Request) {
+	tmpl := `
+<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Сервис</title>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            max-width: 800px;
+            margin: 0 auto;
+            padding: 20px;
+            background-color: #f5f5f5;
+        }
+        .container {
+            background: white;
+            padding: 20px;
+            border-radius: 8px;
+            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
+        }
+        h1 {
+            color: #333;
+            text-align: center;
+        }
+        .service-url {
+            color: #666;
+            text-align: center;
+            margin-bottom: 20px;
+        }
+        .windows {
+            display: flex;
+            gap: 5px;
+            justify-content: center;
+            margin-bottom: 20px;
+        }
+        .windows button {
+            background: #f0f0f0;
+            color: #333;
+            padding: 8px 16px;
+            border: none;
+            border-radius: 4px;
+            cursor: pointer;
+        }
+        .windows button.active {
+            background: #007cba;
+            color: white;
+        }
+        .stats {
+            display: grid;
+            grid-template-columns: repeat(3, 1fr);
+            gap: 10px;
+        }
+        .stat {
+            background: #f9f9f9;
+            border-radius: 4px;
+            padding: 15px;
+            text-align: center;
+        }
+        .stat-value {
+            font-size: 1.5em;
+            font-weight: bold;
+        }
+        .stat-label {
+            color: #666;
+            font-size: 0.9em;
+        }
+        h2 {
+            color: #333;
+            font-size: 1.2em;
+            margin-top: 30px;
+        }
+        #timings {
+            grid-template-columns: repeat(4, 1fr);
+        }
+        .timing-note {
+            color: #666;
+            font-size: 0.9em;
+            margin-top: 10px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1 id="serviceName">Загрузка...</h1>
+        <div class="service-url" id="serviceUrl"></div>
+
+        <div class="windows">
+            <button data-window="1h">1 час</button>
+            <button data-window="24h">24 часа</button>
+            <button data-window="7d">7 дней</button>
+            <button data-window="30d">30 дней</button>
+        </div>
+
+        <div class="stats" id="stats"></div>
+
+        <h2>Этапы запроса</h2>
+        <div class="stats" id="timings"></div>
+        <div class="timing-note" id="timingNote"></div>
+    </div>
+
+    <script>
+        const serviceId = new URLSearchParams(location.search).get('id');
+        let currentWindow = '24h';
+
+        function formatMs(value, checks) {
+            return checks ? value + ' мс' : '—';
+        }
+
+        function renderStats(items) {
+            return items.map(item =>
+                '<div class="stat"><div class="stat-value">' + item[1] + '</div><div class="stat-label">' + item[0] + '</div></div>'
+            ).join('');
+        }
+
+        function loadStats() {
+            document.querySelectorAll('.windows button').forEach(button => {
+                button.classList.toggle('active', button.dataset.window === currentWindow);
+            });
+
+            fetch('api/services/' + encodeURIComponent(serviceId) + '/stats?window=' + currentWindow)
+                .then(response => response.json())
+                .then(result => {
+                    if (result.success === false) {
+                        document.getElementById('serviceName').textContent = result.error;
+                        return;
+                    }
+
+                    document.title = result.service.name;
+                    document.getElementById('serviceName').textContent = result.service.name;
+                    document.getElementById('serviceUrl').textContent = result.service.url;
+
+                    const stats = result.stats;
+                    const items = [
+                        ['Проверок', stats.checks],
+                        ['Доступность', stats.checks ? stats.uptime + '%' : '—'],
+                        ['Среднее', formatMs(stats.avg_ms, stats.checks)],
+                        ['p50', formatMs(stats.p50_ms, stats.checks)],
+                        ['p95', formatMs(stats.p95_ms, stats.checks)],
+                        ['p99', formatMs(stats.p99_ms, stats.checks)]
+                    ];
+                    document.getElementById('stats').innerHTML = renderStats(items);
+
+                    // DNS, соединение и TLS усредняются только по проверкам с новым соединением
+                    const timings = result.timings;
+                    document.getElementById('timings').innerHTML = renderStats([
+                        ['DNS', formatMs(timings.dns_ms, timings.new_connections)],
+                        ['TCP-соединение', formatMs(timings.connect_ms, timings.new_connections)],
+                        ['TLS', formatMs(timings.tls_ms, timings.new_connections)],
+                        ['До первого байта', formatMs(timings.ttfb_ms, timings.checks)]
+                    ]);
+
+                    const last = result.service.timings;
+                    document.getElementById('timingNote').textContent =
+                        'Средние по ' + timings.checks + ' успешным проверкам, новых соединений: ' + timings.new_connections + '.' +
+                        (last ? ' Последняя проверка: DNS ' + last.dns_ms + ' мс, соединение ' + last.connect_ms + ' мс, TLS ' + last.tls_ms +
+                            ' мс, первый байт ' + last.ttfb_ms + ' мс' + (last.reused ? ' (соединение из пула)' : '') + '.' : '');
+                })
+                .catch(error => {
+                    console.error('Ошибка загрузки статистики:', error);
+                    document.getElementById('stats').innerHTML = '<p>Ошибка загрузки статистики</p>';
+                });
+        }
+
+        document.querySelectorAll('.windows button').forEach(button => {
+            button.addEventListener('click', function() {
+                currentWindow = button.dataset.window;
+                loadStats();
+            });
+        });
+
+        loadStats();
+    </script>
+</body>
+</html>
+	`
+
+	writeCached(w, r, "text/html; charset=utf-8", []byte(tmpl))
+}
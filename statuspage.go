@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	colorPattern    = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	hostnamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+)
+
+// StatusPage - оформление публичной страницы статуса и хосты, по которым она открывается
+type StatusPage struct {
+	Title           string   `json:"title"`
+	LogoURL         string   `json:"logo_url"`
+	PrimaryColor    string   `json:"primary_color"`
+	BackgroundColor string   `json:"background_color"`
+	Hosts           []string `json:"hosts"`
+}
+
+type StatusPageStore struct {
+	page     StatusPage
+	mutex    sync.RWMutex
+	filename string
+}
+
+func NewStatusPageStore(filename string) *StatusPageStore {
+	return &StatusPageStore{
+		page:     StatusPage{Hosts: []string{}},
+		filename: filename,
+	}
+}
+
+func (s *StatusPageStore) LoadFromFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла %s: %v", s.filename, err)
+	}
+
+	if err := json.Unmarshal(data, &s.page); err != nil {
+		return fmt.Errorf("ошибка парсинга JSON из файла %s: %v", s.filename, err)
+	}
+	return nil
+}
+
+func (s *StatusPageStore) saveToFile() error {
+	data, err := json.MarshalIndent(s.page, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации в JSON: %v", err)
+	}
+
+	if err := ioutil.WriteFile(s.filename, data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи в файл %s: %v", s.filename, err)
+	}
+	return nil
+}
+
+func (s *StatusPageStore) Get() StatusPage {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	page := s.page
+	page.Hosts = append([]string{}, s.page.Hosts...)
+	return page
+}
+
+func (s *StatusPageStore) Update(page StatusPage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.page = page
+	s.saveToFile()
+}
+
+// HasHost проверяет, привязан ли хост к странице статуса
+func (s *StatusPageStore) HasHost(host string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, h := range s.page.Hosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+// validateStatusPage приводит хосты к нижнему регистру и проверяет поля оформления
+func validateStatusPage(page *StatusPage) error {
+	if page.PrimaryColor != "" && !colorPattern.MatchString(page.PrimaryColor) {
+		return fmt.Errorf("Цвет должен быть в формате #rgb или #rrggbb")
+	}
+	if page.BackgroundColor != "" && !colorPattern.MatchString(page.BackgroundColor) {
+		return fmt.Errorf("Цвет должен быть в формате #rgb или #rrggbb")
+	}
+	if page.LogoURL != "" {
+		u, err := url.Parse(page.LogoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("Адрес логотипа должен начинаться с http:// или https://")
+		}
+	}
+
+	hosts := make([]string, 0, len(page.Hosts))
+	for _, host := range page.Hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" {
+			continue
+		}
+		if !hostnamePattern.MatchString(host) {
+			return fmt.Errorf("Неверное имя хоста: %s", host)
+		}
+		hosts = append(hosts, host)
+	}
+	page.Hosts = hosts
+	return nil
+}
+
+func (ws *Workspace) statusPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		writeJSON(w, ws.statusPage.Get())
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var page StatusPage
+	if err := json.NewDecoder(r.Body).Decode(&page); err != nil {
+		writeError(w, "Неверный формат данных")
+		return
+	}
+
+	if err := validateStatusPage(&page); err != nil {
+		writeError(w, err.Error())
+		return
+	}
+
+	if err := ws.router.CheckHosts(ws, page.Hosts); err != nil {
+		writeError(w, err.Error())
+		return
+	}
+
+	ws.statusPage.Update(page)
+
+	writeJSON(w, map[string]interface{}{
+		"success": true,
+	})
+}
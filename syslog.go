@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+var syslogSeverities = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3,
+	"warning": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// Локальные сокеты syslog в порядке предпочтения
+var syslogLocalSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogNotifier пишет события смены состояния в syslog в формате RFC 5424.
+// Сообщения отправляются в фоне, чтобы недоступный syslog не задерживал проверки
+type SyslogNotifier struct {
+	network      string
+	addr         string
+	facility     int
+	severityDown int
+	severityUp   int
+	hostname     string
+	sender       *netSender
+}
+
+// NewSyslogNotifier создает notifier по адресу вида "local", "udp://host:514"
+// или "tcp://host:601" с заданными facility и уровнями для падения и восстановления
+func NewSyslogNotifier(target, facility, severityDown, severityUp string) (*SyslogNotifier, error) {
+	n := &SyslogNotifier{}
+
+	if target != "local" {
+		u, err := url.Parse(target)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("неверный адрес syslog %q (ожидается local, udp://host:port или tcp://host:port)", target)
+		}
+		if u.Scheme != "udp" && u.Scheme != "tcp" {
+			return nil, fmt.Errorf("неизвестный транспорт syslog %q (поддерживаются udp и tcp)", u.Scheme)
+		}
+		n.network = u.Scheme
+		n.addr = u.Host
+	}
+
+	var ok bool
+	if n.facility, ok = syslogFacilities[facility]; !ok {
+		return nil, fmt.Errorf("неизвестный facility syslog %q", facility)
+	}
+	if n.severityDown, ok = syslogSeverities[severityDown]; !ok {
+		return nil, fmt.Errorf("неизвестный уровень syslog %q", severityDown)
+	}
+	if n.severityUp, ok = syslogSeverities[severityUp]; !ok {
+		return nil, fmt.Errorf("неизвестный уровень syslog %q", severityUp)
+	}
+
+	n.hostname, _ = os.Hostname()
+	if n.hostname == "" {
+		n.hostname = "-"
+	}
+	n.sender = newNetSender("syslog "+target, n.dial)
+	return n, nil
+}
+
+func (n *SyslogNotifier) Notify(event StateChange) error {
+	severity := n.severityDown
+	status := "down"
+	if event.Current {
+		severity = n.severityUp
+		status = "up"
+	}
+
+	params := fmt.Sprintf(`service="%s" url="%s" status="%s"`,
+		escapeSDParam(event.Service.Name),
+		escapeSDParam(event.Service.URL),
+		status,
+	)
+	if event.Failure != nil {
+		params += fmt.Sprintf(` status_line="%s"`, escapeSDParam(event.Failure.StatusLine))
+	}
+
+	return n.write(n.format(severity, event.Time, "STATE", params, event.Message()))
+}
+
+// NotifyDigest отправляет ежедневную сводку о приостановленных сервисах
+// с уровнем падения сервиса
+func (n *SyslogNotifier) NotifyDigest(digest PausedDigest) error {
+	params := fmt.Sprintf(`paused="%d"`, len(digest.Services))
+	return n.write(n.format(n.severityDown, digest.Time, "DIGEST", params, digest.Message()))
+}
+
+// RFC 5424 допускает не больше 6 цифр долей секунды (TIME-SECFRAC),
+// а time.RFC3339Nano выводит до 9
+const syslogTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// format собирает сообщение RFC 5424 с structured data web-monitor@32473
+func (n *SyslogNotifier) format(severity int, t time.Time, msgID, params, message string) string {
+	return fmt.Sprintf("<%d>1 %s %s web-monitor %d %s [web-monitor@32473 %s] %s",
+		n.facility*8+severity,
+		t.Format(syslogTimeLayout),
+		n.hostname,
+		os.Getpid(),
+		msgID,
+		params,
+		message,
+	)
+}
+
+// write ставит сообщение в очередь отправки. Ошибки подключения и записи
+// пишет в журнал фоновая горутина
+func (n *SyslogNotifier) write(msg string) error {
+	// Для TCP используем octet counting (RFC 6587), для датаграмм - одно сообщение на пакет
+	if n.network == "tcp" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	if !n.sender.Send(msg) {
+		return fmt.Errorf("очередь отправки в syslog переполнена, сообщение пропущено")
+	}
+	return nil
+}
+
+func (n *SyslogNotifier) dial() (net.Conn, error) {
+	if n.network != "" {
+		return net.DialTimeout(n.network, n.addr, 5*time.Second)
+	}
+
+	for _, path := range syslogLocalSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("локальный syslog недоступен")
+}
+
+// escapeSDParam экранирует значение параметра structured data по RFC 5424
+func escapeSDParam(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(s)
+}
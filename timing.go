@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// CheckTimings - разбивка времени проверки по этапам запроса. Если проверка
+// прошла по перенаправлениям, все этапы относятся к последнему запросу цепочки,
+// то есть к итоговому ответу. TTFB отсчитывается от начала этого запроса и
+// включает остальные этапы. При повторном использовании соединения DNS,
+// соединение и TLS равны нулю
+type CheckTimings struct {
+	DNSMs     float64 `json:"dns_ms"`
+	ConnectMs float64 `json:"connect_ms"`
+	TLSMs     float64 `json:"tls_ms"`
+	TTFBMs    float64 `json:"ttfb_ms"`
+	Reused    bool    `json:"reused,omitempty"`
+}
+
+// checkTrace собирает моменты этапов запроса через httptrace. Колбэки могут
+// вызываться из разных горутин (параллельное подключение к нескольким адресам).
+// Каждый запрос цепочки перенаправлений начинается с GetConn и сбрасывает
+// собранное для предыдущего, поэтому этапы разных запросов не смешиваются
+type checkTrace struct {
+	mutex        sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+// withCheckTrace добавляет в контекст трассировку запроса проверки
+func withCheckTrace(ctx context.Context) (context.Context, *checkTrace) {
+	t := &checkTrace{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		GetConn:  func(string) { t.reset() },
+		DNSStart: func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone) },
+		ConnectStart: func(string, string) {
+			t.mark(&t.connectStart)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				t.mark(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err == nil {
+				t.mark(&t.tlsDone)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mutex.Lock()
+			t.reused = info.Reused
+			t.mutex.Unlock()
+		},
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}
+	return httptrace.WithClientTrace(ctx, trace), t
+}
+
+// reset начинает отсчет нового запроса
+func (t *checkTrace) reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.start = time.Now()
+	t.dnsStart, t.dnsDone = time.Time{}, time.Time{}
+	t.connectStart, t.connectDone = time.Time{}, time.Time{}
+	t.tlsStart, t.tlsDone = time.Time{}, time.Time{}
+	t.firstByte = time.Time{}
+	t.reused = false
+}
+
+// mark запоминает момент первого наступления этапа
+func (t *checkTrace) mark(at *time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if at.IsZero() {
+		*at = time.Now()
+	}
+}
+
+// Timings возвращает длительности этапов. Незавершенные этапы (например,
+// при ошибке DNS) равны нулю
+func (t *checkTrace) Timings() *CheckTimings {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return &CheckTimings{
+		DNSMs:     phaseMs(t.dnsStart, t.dnsDone),
+		ConnectMs: phaseMs(t.connectStart, t.connectDone),
+		TLSMs:     phaseMs(t.tlsStart, t.tlsDone),
+		TTFBMs:    phaseMs(t.start, t.firstByte),
+		Reused:    t.reused,
+	}
+}
+
+func phaseMs(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return roundTo(float64(end.Sub(start))/float64(time.Millisecond), 1)
+}
+
+// TimingStats - средние длительности этапов за окно. DNS, соединение и TLS
+// усредняются по проверкам с новым соединением, TTFB - по всем успешным
+type TimingStats struct {
+	Checks         int     `json:"checks"`
+	NewConnections int     `json:"new_connections"`
+	DNSMs          float64 `json:"dns_ms"`
+	ConnectMs      float64 `json:"connect_ms"`
+	TLSMs          float64 `json:"tls_ms"`
+	TTFBMs         float64 `json:"ttfb_ms"`
+}
+
+func ComputeTimingStats(results []CheckResult) TimingStats {
+	var stats TimingStats
+	var dns, connect, tlsSum, ttfb float64
+	for _, result := range results {
+		if !result.Status || result.Timings == nil {
+			continue
+		}
+		stats.Checks++
+		ttfb += result.Timings.TTFBMs
+		if !result.Timings.Reused {
+			stats.NewConnections++
+			dns += result.Timings.DNSMs
+			connect += result.Timings.ConnectMs
+			tlsSum += result.Timings.TLSMs
+		}
+	}
+
+	if stats.Checks > 0 {
+		stats.TTFBMs = roundTo(ttfb/float64(stats.Checks), 1)
+	}
+	if stats.NewConnections > 0 {
+		n := float64(stats.NewConnections)
+		stats.DNSMs = roundTo(dns/n, 1)
+		stats.ConnectMs = roundTo(connect/n, 1)
+		stats.TLSMs = roundTo(tlsSum/n, 1)
+	}
+	return stats
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportOptions - настройки HTTP-транспорта, общего для всех проверок
+type TransportOptions struct {
+	// FreshConnections отключает пул соединений: каждая проверка заново
+	// устанавливает TCP и TLS, как это делает новый посетитель
+	FreshConnections      bool
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+}
+
+var defaultTransportOptions = TransportOptions{
+	TLSHandshakeTimeout: 10 * time.Second,
+}
+
+// parseConnectionMode разбирает значение флага -connections: reuse или fresh
+func parseConnectionMode(mode string) (bool, error) {
+	switch mode {
+	case "reuse":
+		return false, nil
+	case "fresh":
+		return true, nil
+	}
+	return false, fmt.Errorf("неизвестный режим соединений %q (поддерживаются reuse и fresh)", mode)
+}
+
+// NewCheckClient создает HTTP-клиент для проверок. Таймаут всей проверки
+// задается контекстом, поэтому у клиента его нет
+func NewCheckClient(opts TransportOptions) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   2,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		DisableKeepAlives:     opts.FreshConnections,
+	}
+
+	return &http.Client{Transport: transport, CheckRedirect: checkRedirect}
+}
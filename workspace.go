@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const defaultWorkspaceID = "default"
+
+var workspaceIDPattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// SyslogConfig - настройки syslog-уведомлений рабочего пространства
+type SyslogConfig struct {
+	Target       string `json:"target"`
+	Facility     string `json:"facility"`
+	SeverityDown string `json:"severity_down"`
+	SeverityUp   string `json:"severity_up"`
+}
+
+// WorkspaceConfig - описание рабочего пространства в файле workspaces.json.
+// Hosts задают начальные хосты страницы статуса, пока они не изменены через API
+type WorkspaceConfig struct {
+	ID     string        `json:"id"`
+	Name   string        `json:"name"`
+	Hosts  []string      `json:"hosts"`
+	Syslog *SyslogConfig `json:"syslog,omitempty"`
+	// Администраторы пространства, пусто - редактирование доступно всем
+	Users []WorkspaceUser `json:"users,omitempty"`
+	// Токен входящих вебхуков пространства
+	WebhookToken string `json:"webhook_token,omitempty"`
+	// Синхронизация списка сервисов из Git
+	GitOps *GitOpsConfig `json:"gitops,omitempty"`
+}
+
+// Workspace - изолированное рабочее пространство со своими сервисами,
+// администраторами, инцидентами, уведомлениями и страницей статуса
+type Workspace struct {
+	ID         string
+	Name       string
+	monitor    *Monitor
+	incidents  *IncidentStore
+	statusPage *StatusPageStore
+	settings   *SettingsStore
+	router     *WorkspaceRouter
+	mux        *http.ServeMux
+	// Администраторы, без которых редактирование доступно всем
+	users []WorkspaceUser
+	// Токен входящих вебхуков, пусто - вебхуки отключены
+	webhookToken string
+	// Синхронизация сервисов из Git, nil - отключена
+	gitops *GitOpsSync
+	dir    string
+}
+
+// NewWorkspace создает рабочее пространство с данными в каталоге dir
+func NewWorkspace(id, name, dir string) *Workspace {
+	ws := &Workspace{
+		ID:         id,
+		Name:       name,
+		monitor:    NewMonitor(filepath.Join(dir, "services.json")),
+		incidents:  NewIncidentStore(filepath.Join(dir, "incidents.json")),
+		statusPage: NewStatusPageStore(filepath.Join(dir, "statuspage.json")),
+		settings:   NewSettingsStore(filepath.Join(dir, "settings.json")),
+		mux:        http.NewServeMux(),
+		dir:        dir,
+	}
+	ws.monitor.history = NewHistoryStore(filepath.Join(dir, "history.jsonl"), defaultHistoryRetention)
+
+	ws.mux.HandleFunc("/", ws.homeHandler)
+	ws.mux.HandleFunc("/edit", ws.editHandler)
+	ws.mux.HandleFunc("/service", ws.serviceDetailHandler)
+	ws.mux.HandleFunc("/api/services", ws.servicesHandler)
+	ws.mux.HandleFunc("/api/services/", ws.serviceItemHandler)
+	ws.mux.HandleFunc("/api/services/check", ws.checkAllServicesHandler)
+	ws.mux.HandleFunc("/api/add", ws.addServiceHandler)
+	ws.mux.HandleFunc("/api/remove", ws.removeServiceHandler)
+	ws.mux.HandleFunc("/api/import", ws.importHandler)
+	ws.mux.HandleFunc("/api/discover", ws.discoverHandler)
+	ws.mux.HandleFunc("/api/webhook/maintenance", ws.maintenanceWebhookHandler)
+	ws.mux.HandleFunc("/api/gitops", ws.gitOpsHandler)
+	ws.mux.HandleFunc("/api/incidents", ws.incidentsHandler)
+	ws.mux.HandleFunc("/api/incidents/add", ws.addIncidentHandler)
+	ws.mux.HandleFunc("/api/incidents/update", ws.updateIncidentHandler)
+	ws.mux.HandleFunc("/api/incidents/remove", ws.removeIncidentHandler)
+	ws.mux.HandleFunc("/api/statuspage", ws.statusPageHandler)
+	ws.mux.HandleFunc("/api/settings", ws.settingsHandler)
+	ws.mux.HandleFunc("/api/reports/compare", ws.compareReportHandler)
+	ws.mux.HandleFunc("/reports/monthly", ws.monthlyReportHandler)
+
+	return ws
+}
+
+// Load загружает сервисы, историю проверок, инциденты, оформление страницы
+// статуса и настройки отображения
+func (ws *Workspace) Load() {
+	if err := ws.monitor.LoadFromFile(); err != nil {
+		log.Printf("Ошибка загрузки сервисов пространства %s: %v", ws.ID, err)
+	}
+	if err := ws.monitor.history.LoadFromFile(); err != nil {
+		log.Printf("Ошибка загрузки истории пространства %s: %v", ws.ID, err)
+	}
+	if err := ws.incidents.LoadFromFile(); err != nil {
+		log.Printf("Ошибка загрузки инцидентов пространства %s: %v", ws.ID, err)
+	}
+	if err := ws.statusPage.LoadFromFile(); err != nil {
+		log.Printf("Ошибка загрузки страницы статуса пространства %s: %v", ws.ID, err)
+	}
+	if err := ws.settings.LoadFromFile(); err != nil {
+		log.Printf("Ошибка загрузки настроек пространства %s: %v", ws.ID, err)
+	}
+}
+
+func (ws *Workspace) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ws.requiresAuth(r) && !ws.authorize(w, r) {
+		return
+	}
+	ws.mux.ServeHTTP(w, r)
+}
+
+// LoadWorkspaceConfigs читает список дополнительных рабочих пространств
+func LoadWorkspaceConfigs(filename string) ([]WorkspaceConfig, error) {
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла %s: %v", filename, err)
+	}
+
+	var configs []WorkspaceConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга JSON из файла %s: %v", filename, err)
+	}
+
+	seen := map[string]bool{defaultWorkspaceID: true}
+	hosts := make(map[string]string)
+	for _, cfg := range configs {
+		if !workspaceIDPattern.MatchString(cfg.ID) {
+			return nil, fmt.Errorf("неверный идентификатор пространства %q (допустимы a-z, 0-9, - и _)", cfg.ID)
+		}
+		if seen[cfg.ID] {
+			return nil, fmt.Errorf("идентификатор пространства %q используется повторно", cfg.ID)
+		}
+		seen[cfg.ID] = true
+
+		if err := validateWorkspaceUsers(cfg.Users); err != nil {
+			return nil, fmt.Errorf("пространство %s: %v", cfg.ID, err)
+		}
+
+		for _, host := range cfg.Hosts {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if owner, ok := hosts[host]; ok && owner != cfg.ID {
+				return nil, fmt.Errorf("хост %s указан в пространствах %s и %s", host, owner, cfg.ID)
+			}
+			hosts[host] = cfg.ID
+		}
+	}
+	return configs, nil
+}
+
+// WorkspaceRouter выбирает рабочее пространство по имени хоста или по
+// префиксу пути /w/<id>/, остальные запросы обслуживает пространство по
+// умолчанию. С хоста, привязанного к пространству, другие пространства
+// недоступны: иначе status.team-a.com/w/team-b/ открывал бы данные команды B
+type WorkspaceRouter struct {
+	workspaces map[string]*Workspace
+	fallback   *Workspace
+}
+
+func NewWorkspaceRouter(fallback *Workspace) *WorkspaceRouter {
+	rt := &WorkspaceRouter{
+		workspaces: make(map[string]*Workspace),
+		fallback:   fallback,
+	}
+	rt.Add(fallback)
+	return rt
+}
+
+func (rt *WorkspaceRouter) Add(ws *Workspace) {
+	ws.router = rt
+	rt.workspaces[ws.ID] = ws
+}
+
+// WorkspaceByHost ищет пространство, страница статуса которого привязана к хосту.
+// Хосты меняются через API, поэтому проверяются при каждом запросе
+func (rt *WorkspaceRouter) WorkspaceByHost(host string) *Workspace {
+	host = strings.ToLower(host)
+	for _, ws := range rt.workspaces {
+		if ws.statusPage.HasHost(host) {
+			return ws
+		}
+	}
+	return nil
+}
+
+// CheckHosts проверяет, что хосты не привязаны к другому пространству:
+// один хост может обслуживать только одно пространство
+func (rt *WorkspaceRouter) CheckHosts(ws *Workspace, hosts []string) error {
+	for _, host := range hosts {
+		if owner := rt.WorkspaceByHost(host); owner != nil && owner != ws {
+			return fmt.Errorf("Хост %s уже используется пространством %s", host, owner.ID)
+		}
+	}
+	return nil
+}
+
+func (rt *WorkspaceRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	hostWorkspace := rt.WorkspaceByHost(host)
+
+	if strings.HasPrefix(r.URL.Path, "/w/") {
+		rest := strings.TrimPrefix(r.URL.Path, "/w/")
+		id := rest
+		if i := strings.Index(rest, "/"); i >= 0 {
+			id = rest[:i]
+		}
+
+		ws, ok := rt.workspaces[id]
+		if !ok || (hostWorkspace != nil && hostWorkspace != ws) {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Относительные ссылки страниц работают только при завершающем слэше
+		if rest == id {
+			http.Redirect(w, r, "/w/"+id+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		http.StripPrefix("/w/"+id, ws).ServeHTTP(w, r)
+		return
+	}
+
+	if hostWorkspace != nil {
+		hostWorkspace.ServeHTTP(w, r)
+		return
+	}
+
+	rt.fallback.ServeHTTP(w, r)
+}